@@ -2,18 +2,36 @@ package main
 
 import (
     "context"
+    "crypto/tls"
     "database/sql"
+    "errors"
     "fmt"
     "log"
+    "log/slog"
+    "net/http"
     "os"
+    "os/signal"
+    "strconv"
+    "strings"
+    "syscall"
     "time"
 
     "github.com/gin-gonic/gin"
     _ "github.com/lib/pq"
     "github.com/nitesh/news_service/internal/api"
+    "github.com/nitesh/news_service/internal/auth"
+    "github.com/nitesh/news_service/internal/compression"
+    "github.com/nitesh/news_service/internal/cors"
+    "github.com/nitesh/news_service/internal/idempotency"
     "github.com/nitesh/news_service/internal/service"
     "github.com/nitesh/news_service/internal/store"
+    "github.com/nitesh/news_service/internal/webhook"
     "github.com/nitesh/news_service/internal/llm"
+    "github.com/nitesh/news_service/internal/logging"
+    "github.com/nitesh/news_service/internal/metrics"
+    "github.com/nitesh/news_service/internal/ratelimit"
+    "github.com/nitesh/news_service/pkg/models"
+    "github.com/prometheus/client_golang/prometheus"
     "github.com/redis/go-redis/v9"
 )
 
@@ -25,7 +43,60 @@ func envOrDefault(key, d string) string {
     return v
 }
 
+// envIntOrDefault parses an integer env var, falling back to d when unset or invalid.
+func envIntOrDefault(key string, d int) int {
+    v := os.Getenv(key)
+    if v == "" {
+        return d
+    }
+    n, err := strconv.Atoi(v)
+    if err != nil {
+        log.Printf("warning: invalid %s=%q, using %d", key, v, d)
+        return d
+    }
+    return n
+}
+
+// envDurationOrDefault parses a duration env var; plain integers are treated as seconds.
+func envDurationOrDefault(key string, d time.Duration) time.Duration {
+    v := os.Getenv(key)
+    if v == "" {
+        return d
+    }
+    if dur, err := time.ParseDuration(v); err == nil {
+        return dur
+    }
+    if secs, err := strconv.Atoi(v); err == nil {
+        return time.Duration(secs) * time.Second
+    }
+    log.Printf("warning: invalid %s=%q, using %s", key, v, d)
+    return d
+}
+
+// envFloatOrDefault parses a float env var, falling back to d when unset or invalid.
+func envFloatOrDefault(key string, d float64) float64 {
+    v := os.Getenv(key)
+    if v == "" {
+        return d
+    }
+    f, err := strconv.ParseFloat(v, 64)
+    if err != nil {
+        log.Printf("warning: invalid %s=%q, using %g", key, v, d)
+        return d
+    }
+    return f
+}
+
 func main() {
+    // LOG_LEVEL (debug, info, warn, error) filters the JSON logs; the standard log
+    // package is routed through the same logger
+    logLevel, err := logging.ParseLevel(envOrDefault("LOG_LEVEL", "info"))
+    if err != nil {
+        log.Fatalf("invalid LOG_LEVEL: %v", err)
+    }
+    logger := logging.New(logLevel)
+    slog.SetDefault(logger)
+
     dbHost := envOrDefault("DB_HOST", "localhost")
     dbPort := envOrDefault("DB_PORT", "5432")
     dbName := envOrDefault("DB_NAME", "scout_db")
@@ -39,6 +110,15 @@ func main() {
     if err != nil {
         log.Fatalf("db open: %v", err)
     }
+    // connection pool sizing
+    maxOpen := envIntOrDefault("DB_MAX_OPEN_CONNS", 25)
+    maxIdle := envIntOrDefault("DB_MAX_IDLE_CONNS", 5)
+    connLifetime := envDurationOrDefault("DB_CONN_MAX_LIFETIME", 5*time.Minute)
+    db.SetMaxOpenConns(maxOpen)
+    db.SetMaxIdleConns(maxIdle)
+    db.SetConnMaxLifetime(connLifetime)
+    log.Printf("db pool: max_open=%d max_idle=%d conn_max_lifetime=%s", maxOpen, maxIdle, connLifetime)
+
     // simple ping + wait (db might be starting in docker)
     for i := 0; i < 10; i++ {
         if err = db.Ping(); err == nil {
@@ -56,7 +136,19 @@ func main() {
         log.Fatalf("migrations: %v", err)
     }
 
-    redisOpts := &redis.Options{Addr: redisAddr}
+    // REDIS_PASSWORD / REDIS_DB / REDIS_USE_TLS for secured or shared Redis servers
+    redisDB, err := strconv.Atoi(envOrDefault("REDIS_DB", "0"))
+    if err != nil || redisDB < 0 {
+        log.Fatalf("invalid REDIS_DB=%q: must be a non-negative integer", os.Getenv("REDIS_DB"))
+    }
+    redisOpts := &redis.Options{
+        Addr:     redisAddr,
+        Password: os.Getenv("REDIS_PASSWORD"),
+        DB:       redisDB,
+    }
+    if envOrDefault("REDIS_USE_TLS", "false") == "true" {
+        redisOpts.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+    }
     rdb := redis.NewClient(redisOpts)
     ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
     defer cancel()
@@ -64,21 +156,247 @@ func main() {
         log.Printf("warning: redis ping failed: %v", err)
     }
 
+    // optional semantic search; requires the pgvector extension on the server
+    embeddingsEnabled := envOrDefault("EMBEDDINGS_ENABLED", "false") == "true"
+    if embeddingsEnabled {
+        if err := store.RunVectorMigrations(db, envIntOrDefault("EMBEDDING_DIMS", 768)); err != nil {
+            log.Fatalf("vector migrations (is pgvector installed?): %v", err)
+        }
+    }
+
+    // nearby search backend: "haversine" (default, plain SQL) or "postgis" (spatial index);
+    // falls back to haversine when the postgis extension isn't available
+    geoBackend := envOrDefault("GEO_BACKEND", "haversine")
+    usePostGIS := false
+    switch geoBackend {
+    case "postgis":
+        if err := store.RunPostGISMigrations(db); err != nil {
+            log.Printf("warning: postgis migrations failed, falling back to haversine: %v", err)
+        } else {
+            usePostGIS = true
+        }
+    case "haversine":
+    default:
+        log.Printf("warning: unknown GEO_BACKEND=%q, using haversine", geoBackend)
+    }
+
     repo := store.NewPgStore(db)
+    repo.SetChunkSize(envIntOrDefault("INGEST_CHUNK_SIZE", 500))
 
-     // create LLM client (reads LLM_URL, LLM_MODEL from env)
-    llmClient := llm.NewClientFromEnv()
+    // metrics live on a dedicated registry (injectable, no global state)
+    reg := prometheus.NewRegistry()
+    m := metrics.New(reg)
+
+     // create LLM client (reads LLM_URL, LLM_MODEL, LLM_PROMPT_TEMPLATE from env)
+    llmClient, err := llm.NewClientFromEnv()
+    if err != nil {
+        log.Fatalf("llm client: %v", err)
+    }
+    llmClient.SetObserver(m)
+    llmClient.SetLogger(func(format string, v ...any) {
+        logger.Debug(fmt.Sprintf(format, v...))
+    })
 
     svc := service.NewService(repo, rdb, llmClient)
+    svc.SetTrendingCacheTTL(envDurationOrDefault("TRENDING_CACHE_TTL", 60*time.Second))
+    svc.SetSearchCacheTTL(envDurationOrDefault("SEARCH_CACHE_TTL", 30*time.Second))
+    svc.SetSummaryCacheTTL(envDurationOrDefault("SUMMARY_CACHE_TTL", 24*time.Hour))
+    svc.SetRelevanceHalfLife(envDurationOrDefault("RELEVANCE_HALF_LIFE", 48*time.Hour))
+    svc.SetLLMConcurrency(envIntOrDefault("LLM_CONCURRENCY", 4))
+    // LLM_MAX_INFLIGHT bounds concurrent LLM calls across all requests (0 = unbounded);
+    // callers waiting longer than LLM_INFLIGHT_WAIT for a slot get 503
+    svc.SetLLMMaxInflight(envIntOrDefault("LLM_MAX_INFLIGHT", 0), envDurationOrDefault("LLM_INFLIGHT_WAIT", 30*time.Second))
+    svc.SetEmbeddingsEnabled(embeddingsEnabled)
+    svc.SetPostGISEnabled(usePostGIS)
+    svc.SetMaxIngestBatch(envIntOrDefault("MAX_INGEST_BATCH", 5000))
+    svc.SetFeedFetchTimeout(envDurationOrDefault("FEED_FETCH_TIMEOUT", 15*time.Second))
+    svc.SetMaxBulkDelete(envIntOrDefault("MAX_BULK_DELETE", 1000))
+    svc.SetMaxIngestSummaries(envIntOrDefault("MAX_INGEST_SUMMARIES", 100))
+    // minimum title similarity (0-1) for /v1/news/search?fuzzy=true
+    svc.SetFuzzyThreshold(envFloatOrDefault("FUZZY_SEARCH_THRESHOLD", 0.3))
+
+    // INGEST_WEBHOOK_URLS (comma-separated) are POSTed {event,count,ids,timestamp} after
+    // each ingest, signed with INGEST_WEBHOOK_SECRET when set
+    var notifier *webhook.Notifier
+    webhookURLs := []string{}
+    for _, u := range strings.Split(os.Getenv("INGEST_WEBHOOK_URLS"), ",") {
+        if u = strings.TrimSpace(u); u != "" {
+            webhookURLs = append(webhookURLs, u)
+        }
+    }
+    if len(webhookURLs) > 0 {
+        notifier = webhook.New(webhookURLs, os.Getenv("INGEST_WEBHOOK_SECRET"), envDurationOrDefault("INGEST_WEBHOOK_TIMEOUT", 5*time.Second))
+        svc.SetIngestNotifier(notifier)
+        if os.Getenv("INGEST_WEBHOOK_SECRET") == "" {
+            log.Printf("warning: INGEST_WEBHOOK_SECRET not set, webhook deliveries are unsigned")
+        }
+    }
+    // extra published_at layouts accepted on ingest (Go layouts, ';'-separated), e.g. "02/01/2006 15:04"
+    if v := os.Getenv("PUBLISHED_AT_LAYOUTS"); v != "" {
+        models.AddPublishedAtLayouts(strings.Split(v, ";")...)
+    }
+    // optional category normalization: CATEGORY_ALIASES is JSON (e.g. {"tech":"technology"}),
+    // CATEGORY_ALLOWLIST is comma-separated; STRICT_CATEGORIES=true drops anything not allowed
+    categoryAllow := envOrDefault("CATEGORY_ALLOWLIST", "")
+    categoryAliases, err := service.ParseCategoryAliases(os.Getenv("CATEGORY_ALIASES"))
+    if err != nil {
+        log.Fatalf("invalid CATEGORY_ALIASES: %v", err)
+    }
+    strictCategories := envOrDefault("STRICT_CATEGORIES", "false") == "true"
+    if strictCategories && categoryAllow == "" && len(categoryAliases) == 0 {
+        log.Printf("warning: STRICT_CATEGORIES=true without CATEGORY_ALLOWLIST or CATEGORY_ALIASES drops every category")
+    }
+    if categoryAllow != "" || len(categoryAliases) > 0 || strictCategories {
+        svc.SetCategoryNormalizer(service.NewCategoryNormalizer(categoryAliases, strings.Split(categoryAllow, ","), strictCategories))
+    }
+    // SEARCH_SOURCE_WEIGHTS (JSON, e.g. {"tabloid":0.5}) scales the search rank per source
+    searchWeights, err := service.ParseSourceWeights(os.Getenv("SEARCH_SOURCE_WEIGHTS"))
+    if err != nil {
+        log.Fatalf("invalid SEARCH_SOURCE_WEIGHTS: %v", err)
+    }
+    svc.SetSearchSourceWeights(searchWeights)
+    // optional server-side relevance at ingest; RELEVANCE_SOURCE_WEIGHTS is JSON, e.g. {"Reuters":1.2}
+    if envOrDefault("RELEVANCE_SCORING", "false") == "true" {
+        weights, err := service.ParseSourceWeights(os.Getenv("RELEVANCE_SOURCE_WEIGHTS"))
+        if err != nil {
+            log.Fatalf("invalid RELEVANCE_SOURCE_WEIGHTS: %v", err)
+        }
+        svc.SetRelevanceScorer(service.DefaultScorer{
+            SourceWeights: weights,
+            RecencyWindow: envDurationOrDefault("RELEVANCE_RECENCY_WINDOW", 72*time.Hour),
+        })
+    }
 
     // svc := service.NewService(repo, rdb)
     handler := api.NewHandler(svc)
+    handler.SetRequestTimeout(envDurationOrDefault("REQUEST_TIMEOUT", 10*time.Second))
+    handler.SetNearbyRadius(envFloatOrDefault("DEFAULT_NEARBY_RADIUS_KM", 10), envFloatOrDefault("MAX_NEARBY_RADIUS_KM", 500))
+    // JSON ingest bodies over this are rejected with 413 before parsing (<= 0 disables)
+    handler.SetMaxIngestBodyBytes(int64(envIntOrDefault("MAX_INGEST_BODY_BYTES", 10<<20)))
+    // per-endpoint maximum ?limit= (defaults in api.DefaultLimits), e.g. LIMIT_MAX_SEARCH=50
+    for endpoint, cfg := range api.DefaultLimits {
+        handler.SetMaxLimit(endpoint, envIntOrDefault("LIMIT_MAX_"+strings.ToUpper(endpoint), cfg.Max))
+    }
+
+    // per-IP rate limits (RPS <= 0 disables); summaries hit the LLM so they get a tighter budget
+    readLimiter := ratelimit.New(rdb, envFloatOrDefault("RATE_LIMIT_RPS", 20), envIntOrDefault("RATE_LIMIT_BURST", 40))
+    summaryLimiter := ratelimit.New(rdb, envFloatOrDefault("SUMMARY_RATE_LIMIT_RPS", 0.5), envIntOrDefault("SUMMARY_RATE_LIMIT_BURST", 5))
+    handler.SetRateLimits(readLimiter.Middleware(), summaryLimiter.Middleware())
+
+    // write endpoints need X-API-Key when API_KEYS is set; unset keeps them open for local dev
+    apiKeys := envOrDefault("API_KEYS", "")
+    if apiKeys == "" {
+        log.Printf("warning: API_KEYS not set, write endpoints are unauthenticated")
+    }
+    handler.SetWriteAuth(auth.APIKey(strings.Split(apiKeys, ",")))
+
+    // ADMIN_ALLOWED_CIDRS limits /v1/admin to trusted networks (unset allows all);
+    // X-Forwarded-For is only honored from ADMIN_TRUSTED_PROXIES (here and for c.ClientIP below)
+    adminCIDRs, err := auth.ParseCIDRs(os.Getenv("ADMIN_ALLOWED_CIDRS"))
+    if err != nil {
+        log.Fatalf("ADMIN_ALLOWED_CIDRS: %v", err)
+    }
+    trustedProxies, err := auth.ParseCIDRs(os.Getenv("ADMIN_TRUSTED_PROXIES"))
+    if err != nil {
+        log.Fatalf("ADMIN_TRUSTED_PROXIES: %v", err)
+    }
+    handler.SetAdminAccess(auth.IPAllowList(adminCIDRs, trustedProxies))
 
-    router := gin.Default()
+    // LOG_LEVEL=debug with LOG_INGEST_BODIES=true logs ingest bodies (first LOG_BODY_MAX_BYTES,
+    // LOG_REDACT_FIELDS masked) to diagnose client payloads
+    if envOrDefault("LOG_INGEST_BODIES", "false") == "true" {
+        if !logger.Enabled(context.Background(), slog.LevelDebug) {
+            log.Printf("warning: LOG_INGEST_BODIES=true has no effect unless LOG_LEVEL=debug")
+        }
+        handler.SetIngestBodyLog(logging.BodyLogger(logger, envIntOrDefault("LOG_BODY_MAX_BYTES", 2048),
+            strings.Split(envOrDefault("LOG_REDACT_FIELDS", "password,token,secret,api_key,authorization"), ",")))
+    }
+
+    // replay ingest responses for a repeated Idempotency-Key within the window (<= 0 disables)
+    handler.SetIdempotency(idempotency.New(rdb, envDurationOrDefault("IDEMPOTENCY_TTL", 24*time.Hour)).Middleware())
+
+    router := gin.New()
+    // c.ClientIP() (rate limit keys, request logs) only reads X-Forwarded-For from the
+    // same ADMIN_TRUSTED_PROXIES; gin otherwise trusts it from any peer
+    proxies := make([]string, len(trustedProxies))
+    for i, p := range trustedProxies {
+        proxies[i] = p.String()
+    }
+    if err := router.SetTrustedProxies(proxies); err != nil {
+        log.Fatalf("ADMIN_TRUSTED_PROXIES: %v", err)
+    }
+    router.Use(gin.Recovery(), logging.RequestLogger(logger))
+    router.Use(m.Middleware())
+    // CORS runs before the v1 routes so preflight OPTIONS requests are answered here
+    if origins := envOrDefault("CORS_ALLOWED_ORIGINS", ""); origins != "" {
+        router.Use(cors.Middleware(strings.Split(origins, ",")))
+    }
+    // gzip responses of at least GZIP_MIN_SIZE bytes; /metrics negotiates its own compression
+    if envOrDefault("ENABLE_GZIP", "false") == "true" {
+        router.Use(compression.Gzip(envIntOrDefault("GZIP_MIN_SIZE", 1024), "/metrics"))
+    }
+    router.GET("/metrics", gin.WrapH(metrics.Handler(reg)))
     api.RegisterRoutes(router, handler)
 
-    log.Printf("listening on :%s", port)
-    if err := router.Run(":" + port); err != nil {
-        log.Fatalf("server failed: %v", err)
+    // background workers for POST /v1/news/:id/summary?async=true
+    workerCtx, stopWorkers := context.WithCancel(context.Background())
+    waitWorkers := svc.StartSummaryWorkers(workerCtx, envIntOrDefault("SUMMARY_WORKERS", 2))
+
+    srv := &http.Server{
+        Addr:    ":" + port,
+        Handler: router,
+    }
+
+    // serve in the background so main can wait for a shutdown signal
+    serverErr := make(chan error, 1)
+    go func() {
+        log.Printf("listening on :%s", port)
+        if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+            serverErr <- err
+        }
+        close(serverErr)
+    }()
+
+    sigCtx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+    defer stop()
+
+    select {
+    case err := <-serverErr:
+        if err != nil {
+            log.Fatalf("server failed: %v", err)
+        }
+    case <-sigCtx.Done():
+        log.Printf("shutdown: signal received, draining connections")
+    }
+
+    // drain in-flight requests, then release DB and Redis
+    shutdownTimeout := envDurationOrDefault("SHUTDOWN_TIMEOUT", 15*time.Second)
+    shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), shutdownTimeout)
+    defer cancelShutdown()
+    if err := srv.Shutdown(shutdownCtx); err != nil {
+        log.Printf("shutdown: http server: %v", err)
+    } else {
+        log.Printf("shutdown: http server stopped")
+    }
+    stopWorkers()
+    waitWorkers()
+    log.Printf("shutdown: summary workers stopped")
+    if notifier != nil {
+        if err := notifier.Close(shutdownCtx); err != nil {
+            log.Printf("shutdown: webhooks: %v", err)
+        } else {
+            log.Printf("shutdown: webhooks delivered")
+        }
+    }
+    if err := db.Close(); err != nil {
+        log.Printf("shutdown: db close: %v", err)
+    } else {
+        log.Printf("shutdown: db closed")
+    }
+    if err := rdb.Close(); err != nil {
+        log.Printf("shutdown: redis close: %v", err)
+    } else {
+        log.Printf("shutdown: redis closed")
     }
+    log.Printf("shutdown: complete")
 }
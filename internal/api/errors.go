@@ -0,0 +1,70 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Machine-readable error codes sent in the "code" field of error responses.
+// Clients should branch on these rather than on the human-readable message.
+// The auth, ratelimit and idempotency middlewares send the same codes.
+const (
+	CodeValidation     = "validation_error"
+	CodeUnauthorized   = "unauthorized"
+	CodeForbidden      = "forbidden"
+	CodeNotFound       = "not_found"
+	CodeConflict       = "conflict"
+	CodeTooLarge       = "payload_too_large"
+	CodeRateLimited    = "rate_limited"
+	CodeInternal       = "internal"
+	CodeNotImplemented = "not_implemented"
+	CodeUpstream       = "upstream_error"
+	CodeUnavailable    = "unavailable"
+)
+
+// codeForStatus returns the error code used for an HTTP status when no more
+// specific code applies.
+func codeForStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeValidation
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusRequestEntityTooLarge:
+		return CodeTooLarge
+	case http.StatusTooManyRequests:
+		return CodeRateLimited
+	case http.StatusNotImplemented:
+		return CodeNotImplemented
+	case http.StatusBadGateway:
+		return CodeUpstream
+	case http.StatusServiceUnavailable:
+		return CodeUnavailable
+	}
+	return CodeInternal
+}
+
+// errorResponse writes the error envelope {"error": msg, "code": code}, plus
+// any extra top-level fields (e.g. meta with partial results). Every handler
+// reports errors through it so clients see one shape.
+func errorResponse(c *gin.Context, status int, code, msg string, extra ...gin.H) {
+	body := gin.H{"error": msg, "code": code}
+	for _, e := range extra {
+		for k, v := range e {
+			body[k] = v
+		}
+	}
+	c.JSON(status, body)
+}
+
+// notFoundRoute answers requests for unknown paths with the error envelope.
+func notFoundRoute(c *gin.Context) {
+	errorResponse(c, http.StatusNotFound, CodeNotFound, "no route for "+c.Request.Method+" "+c.Request.URL.Path)
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// etagWriter buffers the response body so its hash can be sent as an ETag
+// before anything reaches the client.
+type etagWriter struct {
+	gin.ResponseWriter
+	buf bytes.Buffer
+}
+
+func (w *etagWriter) Write(b []byte) (int, error) {
+	return w.buf.Write(b)
+}
+
+func (w *etagWriter) WriteString(s string) (int, error) {
+	return w.buf.WriteString(s)
+}
+
+// withETag sets a weak ETag (hash of the body) on 200 responses and answers
+// 304 Not Modified when it matches If-None-Match. It buffers the whole body,
+// so it's opt-in per route and must not wrap streaming handlers.
+func withETag() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orig := c.Writer
+		w := &etagWriter{ResponseWriter: orig}
+		c.Writer = w
+		c.Next()
+		c.Writer = orig
+
+		if w.Status() != http.StatusOK {
+			orig.Write(w.buf.Bytes())
+			return
+		}
+		sum := sha256.Sum256(w.buf.Bytes())
+		tag := `W/"` + hex.EncodeToString(sum[:16]) + `"`
+		orig.Header().Set("ETag", tag)
+		if etagMatches(c.GetHeader("If-None-Match"), tag) {
+			orig.Header().Del("Content-Type")
+			orig.Header().Del("Content-Length")
+			orig.WriteHeader(http.StatusNotModified)
+			orig.WriteHeaderNow()
+			return
+		}
+		orig.Write(w.buf.Bytes())
+	}
+}
+
+// etagMatches reports whether an If-None-Match header value matches tag,
+// using the weak comparison required for If-None-Match (RFC 9110 13.1.2).
+func etagMatches(header, tag string) bool {
+	if header == "" {
+		return false
+	}
+	want := strings.TrimPrefix(tag, "W/")
+	for _, t := range strings.Split(header, ",") {
+		t = strings.TrimSpace(t)
+		if t == "*" || strings.TrimPrefix(t, "W/") == want {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,123 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nitesh/news_service/pkg/models"
+)
+
+// exportCSVHeader is the column row of a CSV export.
+var exportCSVHeader = []string{"id", "title", "source", "published_at", "url", "categories", "relevance_score"}
+
+// Export: GET /v1/news/export?format=csv&from=2024-01-01&to=2024-01-31
+// Streams every live article as CSV (default) or NDJSON (format=json), in id
+// order, optionally limited to published_at in [from, to] (RFC 3339 or YYYY-MM-DD).
+// Rows are read and flushed in batches, so exports of any size use constant memory.
+// Not bound by the request timeout; a client disconnect stops the export.
+func (h *Handler) Export(c *gin.Context) {
+	format := c.DefaultQuery("format", "csv")
+	if format != "csv" && format != "json" {
+		errorResponse(c, http.StatusBadRequest, CodeValidation, "format must be 'csv' or 'json'")
+		return
+	}
+	var from, to time.Time
+	if v := c.Query("from"); v != "" {
+		t, ok := parseTime(v)
+		if !ok {
+			errorResponse(c, http.StatusBadRequest, CodeValidation, "invalid from: use RFC 3339 or YYYY-MM-DD")
+			return
+		}
+		from = t
+	}
+	if v := c.Query("to"); v != "" {
+		t, ok := parseEndTime(v)
+		if !ok {
+			errorResponse(c, http.StatusBadRequest, CodeValidation, "invalid to: use RFC 3339 or YYYY-MM-DD")
+			return
+		}
+		to = t
+	}
+	if !from.IsZero() && !to.IsZero() && from.After(to) {
+		errorResponse(c, http.StatusBadRequest, CodeValidation, "from must not be after to")
+		return
+	}
+
+	filename := "articles-" + time.Now().UTC().Format("20060102")
+	var write func(*models.Article) error
+	var flush func()
+	if format == "csv" {
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Header("Content-Disposition", `attachment; filename="`+filename+`.csv"`)
+		w := csv.NewWriter(c.Writer)
+		// the header row goes out with the first batch (or alone for an empty export),
+		// so a failing first query can still get a JSON error
+		started := false
+		writeHeader := func() error {
+			if started {
+				return nil
+			}
+			started = true
+			return w.Write(exportCSVHeader)
+		}
+		write = func(a *models.Article) error {
+			if err := writeHeader(); err != nil {
+				return err
+			}
+			return w.Write(csvRecord(a))
+		}
+		flush = func() {
+			_ = writeHeader()
+			w.Flush()
+			c.Writer.Flush()
+		}
+	} else {
+		c.Header("Content-Type", "application/x-ndjson")
+		c.Header("Content-Disposition", `attachment; filename="`+filename+`.ndjson"`)
+		enc := json.NewEncoder(c.Writer)
+		write = func(a *models.Article) error { return enc.Encode(a) }
+		flush = c.Writer.Flush
+	}
+
+	err := h.svc.Export(c.Request.Context(), from, to, write, flush)
+	if err != nil {
+		if !c.Writer.Written() {
+			c.Writer.Header().Del("Content-Type")
+			c.Writer.Header().Del("Content-Disposition")
+			errorResponse(c, http.StatusInternalServerError, CodeInternal, err.Error())
+			return
+		}
+		// the status line is already sent; cut the stream short instead
+		log.Printf("export: %v", err)
+		c.Abort()
+	}
+}
+
+// csvRecord is one CSV export row; categories are joined with ";".
+func csvRecord(a *models.Article) []string {
+	return []string{
+		a.ID,
+		csvSafe(a.Title),
+		csvSafe(a.Source),
+		a.PublishedAt.UTC().Format(time.RFC3339),
+		csvSafe(a.URL),
+		csvSafe(strings.Join(a.Categories, ";")),
+		strconv.FormatFloat(a.Relevance, 'f', -1, 64),
+	}
+}
+
+// csvSafe prefixes text a spreadsheet would evaluate as a formula (leading
+// =, +, -, @, tab or CR) with a single quote, so exported feed content can't
+// inject formulas.
+func csvSafe(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nitesh/news_service/pkg/models"
+)
+
+// parseFields reads ?fields= (comma-separated models.ArticleFields). id is
+// always included. It writes 400 and returns false on an unknown field;
+// nil means "all fields".
+func parseFields(c *gin.Context) ([]string, bool) {
+	raw := splitCSV(c.Query("fields"))
+	if len(raw) == 0 {
+		return nil, true
+	}
+	fields := []string{"id"}
+	seen := map[string]bool{"id": true}
+	for _, f := range raw {
+		f = strings.ToLower(f)
+		if !models.ArticleFields[f] {
+			errorResponse(c, http.StatusBadRequest, CodeValidation, "unknown field "+f)
+			return nil, false
+		}
+		if !seen[f] {
+			seen[f] = true
+			fields = append(fields, f)
+		}
+	}
+	return fields, true
+}
+
+// project trims articles to the requested fields plus any runtime fields the
+// endpoint computes (e.g. distance_km). Without fields the articles are returned as is.
+func project(articles []*models.Article, fields []string, runtime ...string) (any, error) {
+	if len(fields) == 0 {
+		return articles, nil
+	}
+	keep := make(map[string]bool, len(fields)+len(runtime))
+	for _, f := range append(fields, runtime...) {
+		keep[f] = true
+	}
+	out := make([]map[string]json.RawMessage, 0, len(articles))
+	for _, a := range articles {
+		b, err := json.Marshal(a)
+		if err != nil {
+			return nil, err
+		}
+		var all map[string]json.RawMessage
+		if err := json.Unmarshal(b, &all); err != nil {
+			return nil, err
+		}
+		for k := range all {
+			if !keep[k] {
+				delete(all, k)
+			}
+		}
+		out = append(out, all)
+	}
+	return out, nil
+}
@@ -2,181 +2,1643 @@ package api
 
 import (
 	"context"
+	"errors"
 	"math"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/nitesh/news_service/internal/geo"
 	"github.com/nitesh/news_service/internal/service"
 	"github.com/nitesh/news_service/pkg/models"
 )
 
 type Handler struct {
-	svc *service.Service
+	svc            *service.Service
+	requestTimeout time.Duration
+	readLimit      gin.HandlerFunc
+	summaryLimit   gin.HandlerFunc
+	writeAuth      gin.HandlerFunc
+	idempotency    gin.HandlerFunc
+	ingestBodyLog  gin.HandlerFunc
+	adminAccess    gin.HandlerFunc
+	maxIngestBody  int64
+	limits         map[string]LimitConfig
+	defaultRadius  float64
+	maxRadius      float64
 }
 
+// Nearby radius bounds in km unless overridden via SetNearbyRadius.
+const (
+	defaultNearbyRadiusKm = 10
+	maxNearbyRadiusKm     = 500
+)
+
+// defaultRequestTimeout bounds DB-backed handlers unless overridden via SetRequestTimeout.
+const defaultRequestTimeout = 10 * time.Second
+
+// defaultMaxIngestBody caps JSON ingest bodies unless overridden via SetMaxIngestBodyBytes.
+const defaultMaxIngestBody = 10 << 20
+
 func NewHandler(svc *service.Service) *Handler {
-	return &Handler{svc: svc}
+	return &Handler{
+		svc:            svc,
+		requestTimeout: defaultRequestTimeout,
+		maxIngestBody:  defaultMaxIngestBody,
+		limits:         copyLimits(),
+		defaultRadius:  defaultNearbyRadiusKm,
+		maxRadius:      maxNearbyRadiusKm,
+	}
+}
+
+// SetNearbyRadius sets the radius (km) Nearby uses when ?radius= is omitted and
+// the largest radius it accepts; larger requests are reduced to max.
+// Non-positive values keep the current setting.
+func (h *Handler) SetNearbyRadius(def, max float64) {
+	if max > 0 {
+		h.maxRadius = max
+	}
+	if def > 0 {
+		h.defaultRadius = def
+	}
+	if h.defaultRadius > h.maxRadius {
+		h.defaultRadius = h.maxRadius
+	}
+}
+
+// SetRequestTimeout sets the deadline applied to each request's context.
+// A non-positive value disables the deadline (client disconnects still cancel).
+func (h *Handler) SetRequestTimeout(d time.Duration) {
+	h.requestTimeout = d
+}
+
+// SetMaxIngestBodyBytes caps the size of JSON ingest request bodies; larger
+// ones are rejected with 413 before they are parsed. NDJSON ingest streams and
+// is not capped. A non-positive value removes the cap.
+func (h *Handler) SetMaxIngestBodyBytes(n int64) {
+	h.maxIngestBody = n
+}
+
+// limitBody caps the request body at max bytes (max <= 0 disables the cap).
+// A declared Content-Length over the cap is answered with 413 right away and
+// false is returned; otherwise reading past the cap fails (see bodyTooLarge).
+func limitBody(c *gin.Context, max int64) bool {
+	if max <= 0 {
+		return true
+	}
+	if c.Request.ContentLength > max {
+		errorResponse(c, http.StatusRequestEntityTooLarge, CodeTooLarge, bodyTooLargeMsg(max))
+		return false
+	}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, max)
+	return true
+}
+
+// bodyTooLarge reports whether err came from reading past a limitBody cap.
+func bodyTooLarge(err error) bool {
+	var mbe *http.MaxBytesError
+	return errors.As(err, &mbe)
+}
+
+// bodyTooLargeMsg is the 413 message for a body over max bytes.
+func bodyTooLargeMsg(max int64) string {
+	return "request body exceeds the limit of " + strconv.FormatInt(max, 10) + " bytes"
+}
+
+// SetRateLimits installs rate-limiting middleware: summary applies to the
+// LLM-backed summary endpoints, read to everything else under /v1.
+// A nil middleware leaves that group unlimited.
+func (h *Handler) SetRateLimits(read, summary gin.HandlerFunc) {
+	h.readLimit = read
+	h.summaryLimit = summary
+}
+
+// SetWriteAuth installs the middleware guarding mutating routes (ingest, update,
+// delete, summaries and admin). A nil middleware leaves them open.
+func (h *Handler) SetWriteAuth(mw gin.HandlerFunc) {
+	h.writeAuth = mw
+}
+
+// SetIdempotency installs the middleware honoring Idempotency-Key on the
+// ingest routes. A nil middleware disables it.
+func (h *Handler) SetIdempotency(mw gin.HandlerFunc) {
+	h.idempotency = mw
+}
+
+// SetIngestBodyLog installs a middleware logging request/response bodies of the
+// ingest routes (see logging.BodyLogger). A nil middleware disables it.
+func (h *Handler) SetIngestBodyLog(mw gin.HandlerFunc) {
+	h.ingestBodyLog = mw
+}
+
+// SetAdminAccess installs the middleware restricting the /v1/admin routes
+// (e.g. to trusted networks), in addition to the write auth. A nil middleware
+// adds no restriction.
+func (h *Handler) SetAdminAccess(mw gin.HandlerFunc) {
+	h.adminAccess = mw
+}
+
+// orPassthrough returns mw, or a no-op middleware when mw is nil.
+func orPassthrough(mw gin.HandlerFunc) gin.HandlerFunc {
+	if mw == nil {
+		return func(c *gin.Context) { c.Next() }
+	}
+	return mw
+}
+
+// requestContext derives the context for a request: it is cancelled when the
+// client disconnects or the configured timeout elapses.
+func (h *Handler) requestContext(c *gin.Context) (context.Context, context.CancelFunc) {
+	if h.requestTimeout <= 0 {
+		return context.WithCancel(c.Request.Context())
+	}
+	return context.WithTimeout(c.Request.Context(), h.requestTimeout)
 }
 
 func RegisterRoutes(r *gin.Engine, h *Handler) {
-	v1 := r.Group("/v1")
+	r.NoRoute(notFoundRoute)
+	r.GET("/healthz", h.Health)
+
+	// withETag lets polling clients revalidate with If-None-Match (304 when unchanged)
+	v1 := r.Group("/v1", orPassthrough(h.readLimit))
 	{
-		v1.POST("/news/ingest", h.Ingest)
-		v1.GET("/news/search", h.Search)
-		v1.GET("/news/category", h.Category)
-		v1.GET("/news/trending", h.Trending)
+		v1.GET("/news/search", withETag(), h.Search)
+		v1.GET("/news/semantic-search", h.SemanticSearch)
+		v1.GET("/news/category", withETag(), h.Category)
+		v1.GET("/news/trending", withETag(), h.Trending)
 		v1.GET("/news/nearby", h.Nearby)
-		v1.POST("/news/:id/summary", h.GenerateSummary)
+		v1.GET("/news/bbox", h.BoundingBox)
+		v1.GET("/news/archive", withETag(), h.Archive)
+		v1.GET("/news/recent", withETag(), h.Recent)
+		v1.GET("/news/export", h.Export)
+		v1.GET("/news/similar/:id", h.Similar)
+		v1.GET("/news/sources", withETag(), h.Sources)
+		v1.GET("/news/categories", withETag(), h.Categories)
+		v1.GET("/news/stats", withETag(), h.Stats)
+		v1.GET("/news/unsummarized", h.Unsummarized)
+		v1.GET("/news/ungeocoded", h.Ungeocoded)
+		v1.GET("/news/:id", withETag(), h.GetArticle)
+		v1.GET("/news/:id/summary", h.GetSummary)
 	}
+
+	// mutating and admin routes require an API key (when configured)
+	write := v1.Group("", orPassthrough(h.writeAuth))
+	{
+		write.POST("/news/ingest", orPassthrough(h.ingestBodyLog), orPassthrough(h.idempotency), h.Ingest)
+		write.POST("/news/ingest/feed", orPassthrough(h.ingestBodyLog), orPassthrough(h.idempotency), h.IngestFeed)
+		write.PUT("/news/:id", h.UpdateArticle)
+		write.PUT("/news/:id/geo", h.UpdateGeo)
+		write.DELETE("/news/:id", h.DeleteArticle)
+		write.POST("/news/bulk-delete", h.BulkDelete)
+		write.POST("/news/reindex", orPassthrough(h.adminAccess), h.Reindex)
+	}
+
+	// admin routes are additionally limited to trusted networks (when configured)
+	admin := write.Group("/admin", orPassthrough(h.adminAccess))
+	{
+		admin.POST("/recompute-relevance", h.RecomputeRelevance)
+		admin.GET("/deleted", h.DeletedArticles)
+		admin.GET("/duplicates", h.Duplicates)
+		admin.POST("/news/:id/restore", h.RestoreArticle)
+		admin.GET("/schema", h.SchemaStatus)
+		admin.POST("/migrate", h.Migrate)
+		admin.GET("/search-weights", h.SearchWeights)
+	}
+
+	// LLM-backed endpoints get their own, stricter limit
+	summary := r.Group("/v1", orPassthrough(h.summaryLimit), orPassthrough(h.writeAuth))
+	{
+		summary.POST("/news/:id/summary", h.GenerateSummary)
+		summary.POST("/news/summary/batch", h.GenerateSummaryBatch)
+	}
+}
+
+// Health: GET /healthz
+// Pings Postgres and Redis; 200 when both are reachable, 503 (with code "unavailable") otherwise.
+func (h *Handler) Health(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+	defer cancel()
+
+	status := http.StatusOK
+	res := gin.H{"db": "ok", "redis": "ok"}
+	if err := h.svc.DB().PingContext(ctx); err != nil {
+		status = http.StatusServiceUnavailable
+		res["db"] = err.Error()
+	}
+	if rdb := h.svc.Redis(); rdb == nil {
+		status = http.StatusServiceUnavailable
+		res["redis"] = "not configured"
+	} else if err := rdb.Ping(ctx).Err(); err != nil {
+		status = http.StatusServiceUnavailable
+		res["redis"] = err.Error()
+	}
+	if status != http.StatusOK {
+		res["code"] = CodeUnavailable
+	}
+	c.JSON(status, res)
 }
 
-// Ingest: POST /v1/news/ingest
-// Body: JSON array of articles
+// Ingest: POST /v1/news/ingest?auto_categorize=true&dry_run=true&summarize=true&async=true&rescore=true
+// Body: JSON array of articles, or one article per line with Content-Type: application/x-ndjson
+// (see ingestNDJSON).
+// With auto_categorize=true, articles without categories are classified by the LLM.
+// With rescore=true server-side relevance scoring (when enabled) overrides client relevance.
+// default_source is stored for articles without a source (else their URL's host, minus "www.").
+// With dry_run=true nothing is written; meta reports would_insert, would_update and validation errors.
+// With summarize=true the stored articles are summarized before responding, or queued
+// for the summary workers with async=true; meta.summaries reports the outcome.
 func (h *Handler) Ingest(c *gin.Context) {
+	autoCategorize, err := strconv.ParseBool(c.DefaultQuery("auto_categorize", "false"))
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, CodeValidation, "invalid auto_categorize value")
+		return
+	}
+	dryRun, err := strconv.ParseBool(c.DefaultQuery("dry_run", "false"))
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, CodeValidation, "invalid dry_run value")
+		return
+	}
+	rescore, err := strconv.ParseBool(c.DefaultQuery("rescore", "false"))
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, CodeValidation, "invalid rescore value")
+		return
+	}
+	summarize, err := strconv.ParseBool(c.DefaultQuery("summarize", "false"))
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, CodeValidation, "invalid summarize value")
+		return
+	}
+	async, err := strconv.ParseBool(c.DefaultQuery("async", "false"))
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, CodeValidation, "invalid async value")
+		return
+	}
+	opts := service.IngestOptions{AutoCategorize: autoCategorize, Rescore: rescore, DefaultSource: c.Query("default_source")}
+
+	if c.ContentType() == "application/x-ndjson" {
+		if dryRun || summarize {
+			errorResponse(c, http.StatusBadRequest, CodeValidation, "dry_run and summarize are not supported for NDJSON ingest")
+			return
+		}
+		h.ingestNDJSON(c, opts)
+		return
+	}
+
+	if !limitBody(c, h.maxIngestBody) {
+		return
+	}
 	var payload []*models.Article
-	if err := c.BindJSON(&payload); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json: " + err.Error()})
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		if bodyTooLarge(err) {
+			errorResponse(c, http.StatusRequestEntityTooLarge, CodeTooLarge, bodyTooLargeMsg(h.maxIngestBody))
+			return
+		}
+		errorResponse(c, http.StatusBadRequest, CodeValidation, "invalid json: "+err.Error())
+		return
+	}
+	ctx, cancel := h.requestContext(c)
+	defer cancel()
+
+	if dryRun {
+		plan, err := h.svc.DryRunIngest(ctx, payload)
+		if err != nil {
+			if errors.Is(err, service.ErrIngestTooLarge) {
+				errorResponse(c, http.StatusRequestEntityTooLarge, CodeTooLarge, err.Error())
+				return
+			}
+			errorResponse(c, http.StatusInternalServerError, CodeInternal, "dry run failed: "+err.Error())
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"meta": gin.H{
+				"dry_run":      true,
+				"would_insert": plan.WouldInsert,
+				"would_update": plan.WouldUpdate,
+				"errors":       plan.Errors,
+			},
+		})
+		return
+	}
+
+	ids, err := h.svc.Ingest(ctx, payload, opts)
+	if err != nil {
+		if errors.Is(err, service.ErrIngestTooLarge) {
+			errorResponse(c, http.StatusRequestEntityTooLarge, CodeTooLarge, err.Error())
+			return
+		}
+		var invalid *service.ValidationError
+		if errors.As(err, &invalid) {
+			errorResponse(c, http.StatusBadRequest, CodeValidation, err.Error(), gin.H{
+				"meta": gin.H{"errors": invalid.Errors},
+			})
+			return
+		}
+		status, code := http.StatusInternalServerError, CodeInternal
+		if errors.Is(err, models.ErrArticleDeleted) {
+			status, code = http.StatusConflict, CodeConflict
+		}
+		var partial *models.PartialSaveError
+		if errors.As(err, &partial) {
+			errorResponse(c, status, code, "ingest failed: "+err.Error(), gin.H{
+				"meta": gin.H{
+					"imported":         partial.Saved,
+					"chunks_committed": partial.ChunksCommitted,
+					"chunks_total":     partial.ChunksTotal,
+				},
+			})
+			return
+		}
+		errorResponse(c, status, code, "ingest failed: "+err.Error())
+		return
+	}
+	meta := gin.H{"imported": len(payload)}
+	if summarize {
+		// LLM calls outlast the request timeout, so only a client disconnect cancels them
+		report, err := h.svc.SummarizeIngested(c.Request.Context(), ids, async)
+		if err != nil {
+			// the articles are already stored; report the summary failure alongside them
+			meta["summaries_error"] = err.Error()
+		} else {
+			meta["summaries"] = report
+		}
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"meta": meta,
+		"ids":  ids,
+	})
+}
+
+// ingestNDJSON streams an application/x-ndjson body into the store in batches.
+// meta reports parsed, failed and imported line counts plus the first line errors.
+// Batches are committed as they fill, so a failure midway returns 500 with the
+// counts of what was already saved.
+func (h *Handler) ingestNDJSON(c *gin.Context, opts service.IngestOptions) {
+	// large crawls outlast the request timeout; a client disconnect still cancels
+	rep, err := h.svc.IngestNDJSON(c.Request.Context(), c.Request.Body, opts)
+	if err != nil {
+		status, code := http.StatusInternalServerError, CodeInternal
+		if errors.Is(err, models.ErrArticleDeleted) {
+			status, code = http.StatusConflict, CodeConflict
+		}
+		errorResponse(c, status, code, "ingest failed: "+err.Error(), gin.H{
+			"meta": rep,
+		})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"meta": rep})
+}
+
+// IngestFeed: POST /v1/news/ingest/feed
+// Body: {"url": "https://example.com/rss.xml"}
+// Fetches an RSS 2.0 / Atom feed server-side and ingests its items. Their source is
+// ?default_source= when given, else the channel title, else the feed URL's host.
+func (h *Handler) IngestFeed(c *gin.Context) {
+	if !limitBody(c, h.maxIngestBody) {
 		return
 	}
-	ctx := context.Background()
-	if err := h.svc.Ingest(ctx, payload); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "ingest failed: " + err.Error()})
+	var body struct {
+		URL string `json:"url"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		if bodyTooLarge(err) {
+			errorResponse(c, http.StatusRequestEntityTooLarge, CodeTooLarge, bodyTooLargeMsg(h.maxIngestBody))
+			return
+		}
+		errorResponse(c, http.StatusBadRequest, CodeValidation, "invalid json: "+err.Error())
+		return
+	}
+	u, err := url.Parse(body.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		errorResponse(c, http.StatusBadRequest, CodeValidation, "url must be an absolute http(s) URL")
+		return
+	}
+
+	// the download has its own deadline (FEED_FETCH_TIMEOUT), so don't apply the
+	// DB request timeout; a client disconnect still cancels
+	title, n, err := h.svc.IngestFeed(c.Request.Context(), body.URL, c.Query("default_source"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrFeedForbidden) {
+			status = http.StatusBadRequest
+		} else if errors.Is(err, service.ErrFeedUnavailable) {
+			status = http.StatusBadGateway
+		} else if errors.Is(err, service.ErrIngestTooLarge) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		errorResponse(c, status, codeForStatus(status), "feed ingest failed: "+err.Error())
 		return
 	}
 	c.JSON(http.StatusCreated, gin.H{
-		"meta": gin.H{"imported": len(payload)},
+		"meta": gin.H{"imported": n, "feed_title": title},
 	})
 }
 
-// Search: GET /v1/news/search?q=...&limit=10
+// Search: GET /v1/news/search?q=...&limit=10&cursor=...&sort=published_desc&source=BBC,CNN&lang=en&fields=title,url
+// Pass meta.next_cursor back as cursor to fetch the next page (default sort only).
+// Alternatively page=2&page_size=20 pages by number (not with cursor); meta adds total_pages.
+// fields (on all list endpoints taking it) limits the returned article fields; id is always included.
+// fuzzy=true matches titles by trigram similarity, tolerating typos (requires q).
+// highlight=true adds a highlight field: the HTML-escaped description with the
+// matched terms in <mark> tags (plain escaped description for fuzzy searches).
 func (h *Handler) Search(c *gin.Context) {
 	q := c.Query("q")
-	lim := parseLimit(c.DefaultQuery("limit", "10"))
-	ctx := context.Background()
-	res, err := h.svc.Search(ctx, q, lim)
+	lim, clamped := h.limit(c, "search")
+	cursor := c.Query("cursor")
+	pg, ok := h.page(c, "search")
+	if !ok {
+		return
+	}
+	if pg.Page > 0 {
+		lim, clamped = pg.Size, pg.Clamped
+	}
+	sort, ok := parseSort(c)
+	if !ok {
+		return
+	}
+	filter, ok := parseFilter(c)
+	if !ok {
+		return
+	}
+	if filter.Fields, ok = parseFields(c); !ok {
+		return
+	}
+	// ?nocache=true skips the Redis result cache (debugging)
+	noCache, err := strconv.ParseBool(c.DefaultQuery("nocache", "false"))
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, CodeValidation, "invalid nocache value")
+		return
+	}
+	fuzzy, err := strconv.ParseBool(c.DefaultQuery("fuzzy", "false"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		errorResponse(c, http.StatusBadRequest, CodeValidation, "invalid fuzzy value")
+		return
+	}
+	if fuzzy && strings.TrimSpace(q) == "" {
+		errorResponse(c, http.StatusBadRequest, CodeValidation, "fuzzy search requires q")
+		return
+	}
+	if filter.Highlight, err = strconv.ParseBool(c.DefaultQuery("highlight", "false")); err != nil {
+		errorResponse(c, http.StatusBadRequest, CodeValidation, "invalid highlight value")
+		return
+	}
+	ctx, cancel := h.requestContext(c)
+	defer cancel()
+	res, next, total, err := h.svc.Search(ctx, q, filter, sort, lim, pg.offset(), cursor, fuzzy, noCache)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCursor) {
+			errorResponse(c, http.StatusBadRequest, CodeValidation, err.Error())
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, CodeInternal, err.Error())
+		return
+	}
+	data, err := project(res, filter.Fields, "search_rank", "highlight")
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, CodeInternal, err.Error())
+		return
+	}
+	meta := gin.H{
+		"query":         q,
+		"fuzzy":         fuzzy,
+		"highlight":     filter.Highlight,
+		"min_relevance": filter.MinRelevance,
+		"count":         len(res),
+		"total":         total,
+		"limit":         lim,
+		"limit_clamped": clamped,
+		"next_cursor":   next,
+	}
+	addPageMeta(meta, pg, total)
+	c.JSON(http.StatusOK, gin.H{
+		"meta": meta,
+		"data": data,
+	})
+}
+
+// SemanticSearch: GET /v1/news/semantic-search?q=...&limit=10
+// Embeds q and returns the nearest articles by cosine similarity (needs pgvector).
+func (h *Handler) SemanticSearch(c *gin.Context) {
+	q := c.Query("q")
+	if q == "" {
+		errorResponse(c, http.StatusBadRequest, CodeValidation, "missing q parameter")
+		return
+	}
+	lim, clamped := h.limit(c, "semantic_search")
+	// embedding the query is an LLM round-trip, so don't apply the DB request timeout
+	res, err := h.svc.SemanticSearch(c.Request.Context(), q, lim)
+	if err != nil {
+		if errors.Is(err, service.ErrEmbeddingsDisabled) {
+			errorResponse(c, http.StatusNotImplemented, CodeNotImplemented, err.Error())
+			return
+		}
+		if errors.Is(err, service.ErrLLMBusy) {
+			errorResponse(c, http.StatusServiceUnavailable, CodeUnavailable, err.Error())
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, CodeInternal, err.Error())
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{
 		"meta": gin.H{
-			"query": q,
-			"count": len(res),
-			"limit": lim,
+			"query":         q,
+			"count":         len(res),
+			"limit":         lim,
+			"limit_clamped": clamped,
 		},
 		"data": res,
 	})
 }
 
-// Category: GET /v1/news/category?category=Technology&limit=10
+// Category: GET /v1/news/category?category=Technology,AI&match=all&limit=10&sort=title_asc
+// category is a comma-separated list; match is "any" (default) or "all".
+// An optional source (comma-separated, case-insensitive) restricts publishers, lang the language.
+// page and page_size page by number as in Search.
 func (h *Handler) Category(c *gin.Context) {
 	category := c.Query("category")
-	if category == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "missing category parameter"})
+	categories := splitCSV(category)
+	if len(categories) == 0 {
+		errorResponse(c, http.StatusBadRequest, CodeValidation, "missing category parameter")
+		return
+	}
+	match := c.DefaultQuery("match", "any")
+	if match != "any" && match != "all" {
+		errorResponse(c, http.StatusBadRequest, CodeValidation, "match must be 'any' or 'all'")
+		return
+	}
+	lim, clamped := h.limit(c, "category")
+	pg, ok := h.page(c, "category")
+	if !ok {
+		return
+	}
+	if pg.Page > 0 {
+		lim, clamped = pg.Size, pg.Clamped
+	}
+	sort, ok := parseSort(c)
+	if !ok {
+		return
+	}
+	filter, ok := parseFilter(c)
+	if !ok {
+		return
+	}
+	if filter.Fields, ok = parseFields(c); !ok {
 		return
 	}
-	lim := parseLimit(c.DefaultQuery("limit", "10"))
-	ctx := context.Background()
-	res, err := h.svc.Category(ctx, category, lim)
+	ctx, cancel := h.requestContext(c)
+	defer cancel()
+	res, total, err := h.svc.Category(ctx, categories, match == "all", filter, sort, lim, pg.offset())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		errorResponse(c, http.StatusInternalServerError, CodeInternal, err.Error())
+		return
+	}
+	data, err := project(res, filter.Fields)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, CodeInternal, err.Error())
+		return
+	}
+	meta := gin.H{
+		"category":      category,
+		"match":         match,
+		"min_relevance": filter.MinRelevance,
+		"count":         len(res),
+		"total":         total,
+		"limit":         lim,
+		"limit_clamped": clamped,
+	}
+	addPageMeta(meta, pg, total)
+	c.JSON(http.StatusOK, gin.H{
+		"meta": meta,
+		"data": data,
+	})
+}
+
+// maxTrendingWindow caps the trending window at 30 days (in hours).
+const maxTrendingWindow = 720
+
+// Trending: GET /v1/news/trending?limit=10&sort=published_desc&source=BBC&lang=en&window=24
+// window (hours) ranks recent articles by recency-weighted relevance instead of sort.
+func (h *Handler) Trending(c *gin.Context) {
+	lim, clamped := h.limit(c, "trending")
+	sort, ok := parseSort(c)
+	if !ok {
+		return
+	}
+	window := 0
+	if raw := c.Query("window"); raw != "" {
+		w, err := strconv.Atoi(raw)
+		if err != nil || w < 1 || w > maxTrendingWindow {
+			errorResponse(c, http.StatusBadRequest, CodeValidation, "window must be an integer number of hours between 1 and "+strconv.Itoa(maxTrendingWindow))
+			return
+		}
+		if c.Query("sort") != "" {
+			errorResponse(c, http.StatusBadRequest, CodeValidation, "sort cannot be combined with window")
+			return
+		}
+		window = w
+	}
+	filter, ok := parseFilter(c)
+	if !ok {
+		return
+	}
+	if filter.Fields, ok = parseFields(c); !ok {
+		return
+	}
+	ctx, cancel := h.requestContext(c)
+	defer cancel()
+	res, err := h.svc.Trending(ctx, filter, sort, window, lim)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, CodeInternal, err.Error())
+		return
+	}
+	data, err := project(res, filter.Fields)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, CodeInternal, err.Error())
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{
 		"meta": gin.H{
-			"category": category,
-			"count":    len(res),
-			"limit":    lim,
+			"count":         len(res),
+			"limit":         lim,
+			"limit_clamped": clamped,
+			"window":        window,
+			"min_relevance": filter.MinRelevance,
+		},
+		"data": data,
+	})
+}
+
+// Sources: GET /v1/news/sources?limit=20
+// Lists distinct sources with article counts; limit is optional (all sources by default).
+func (h *Handler) Sources(c *gin.Context) {
+	lim, clamped := h.limit(c, "sources")
+	ctx, cancel := h.requestContext(c)
+	defer cancel()
+	res, err := h.svc.Sources(ctx, lim)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, CodeInternal, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"meta": gin.H{
+			"count":         len(res),
+			"limit":         lim,
+			"limit_clamped": clamped,
 		},
 		"data": res,
 	})
 }
 
-// Trending: GET /v1/news/trending?limit=10
-func (h *Handler) Trending(c *gin.Context) {
-	lim := parseLimit(c.DefaultQuery("limit", "10"))
-	ctx := context.Background()
-	res, err := h.svc.Trending(ctx, lim)
+// Categories: GET /v1/news/categories?limit=20
+// Lists distinct categories with article counts; limit is optional (all categories by default).
+func (h *Handler) Categories(c *gin.Context) {
+	lim, clamped := h.limit(c, "categories")
+	ctx, cancel := h.requestContext(c)
+	defer cancel()
+	res, err := h.svc.Categories(ctx, lim)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		errorResponse(c, http.StatusInternalServerError, CodeInternal, err.Error())
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{
 		"meta": gin.H{
-			"count": len(res),
-			"limit": lim,
+			"count":         len(res),
+			"limit":         lim,
+			"limit_clamped": clamped,
 		},
 		"data": res,
 	})
 }
 
-// Nearby: GET /v1/news/nearby?lat=12.97&lon=77.59&radius=10&limit=20
+// Stats: GET /v1/news/stats
+// Aggregate counts for dashboards; cached for up to a minute (see generated_at).
+func (h *Handler) Stats(c *gin.Context) {
+	ctx, cancel := h.requestContext(c)
+	defer cancel()
+	st, err := h.svc.Stats(ctx)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, CodeInternal, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, st)
+}
+
+// Unsummarized: GET /v1/news/unsummarized?limit=50&after=<id>
+// Lists articles without an LLM summary for backfills. Pass meta.next_after as
+// after to get the next page; it's empty on the last page.
+func (h *Handler) Unsummarized(c *gin.Context) {
+	limit, clamped := h.limit(c, "unsummarized")
+	after := c.Query("after")
+	ctx, cancel := h.requestContext(c)
+	defer cancel()
+	results, err := h.svc.UnsummarizedArticles(ctx, after, limit)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidID) {
+			errorResponse(c, http.StatusBadRequest, CodeValidation, "invalid after value")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, CodeInternal, err.Error())
+		return
+	}
+	nextAfter := ""
+	if len(results) > 0 && len(results) == limit {
+		nextAfter = results[len(results)-1].ID
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"meta": gin.H{
+			"count":         len(results),
+			"limit":         limit,
+			"limit_clamped": clamped,
+			"next_after":    nextAfter,
+		},
+		"data": results,
+	})
+}
+
+// Ungeocoded: GET /v1/news/ungeocoded?limit=50&after=<id>
+// Lists articles without coordinates for geocoding backfills,
+// paged like Unsummarized; write results back with PUT /v1/news/:id/geo.
+func (h *Handler) Ungeocoded(c *gin.Context) {
+	limit, clamped := h.limit(c, "ungeocoded")
+	after := c.Query("after")
+	ctx, cancel := h.requestContext(c)
+	defer cancel()
+	results, err := h.svc.UngeocodedArticles(ctx, after, limit)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidID) {
+			errorResponse(c, http.StatusBadRequest, CodeValidation, "invalid after value")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, CodeInternal, err.Error())
+		return
+	}
+	nextAfter := ""
+	if len(results) > 0 && len(results) == limit {
+		nextAfter = results[len(results)-1].ID
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"meta": gin.H{
+			"count":         len(results),
+			"limit":         limit,
+			"limit_clamped": clamped,
+			"next_after":    nextAfter,
+		},
+		"data": results,
+	})
+}
+
+// maxNearbyOffset bounds how deep clients can page through nearby results.
+const maxNearbyOffset = 10000
+
+// Nearby: GET /v1/news/nearby?lat=12.97&lon=77.59&radius=10&limit=20&offset=20&unit=km
+// meta.max_distance_km is the distance of the farthest article on this page.
+// radius defaults to the configured default and is reduced to the configured
+// maximum (meta.radius_km is the effective radius, meta.radius_clamped whether it was reduced).
+// With unit=mi, radius is read in miles and articles carry distance_mi instead of
+// distance_km; meta.radius and meta.max_distance are in the requested unit.
+// meta.partial is true when the database couldn't run the distance query and
+// the in-process fallback had to skip some candidates.
 func (h *Handler) Nearby(c *gin.Context) {
 	q := c.Request.URL.Query()
 
+	unit := strings.ToLower(c.DefaultQuery("unit", "km"))
+	if unit != "km" && unit != "mi" {
+		errorResponse(c, http.StatusBadRequest, CodeValidation, "unit must be km or mi")
+		return
+	}
 	lat, latErr := strconv.ParseFloat(q.Get("lat"), 64)
 	lon, lonErr := strconv.ParseFloat(q.Get("lon"), 64)
-	radius, radiusErr := strconv.ParseFloat(q.Get("radius"), 64)
-	limit := parseLimit(c.DefaultQuery("limit", "20"))
+	radius, radiusErr := h.defaultRadius, error(nil)
+	if v := q.Get("radius"); v != "" {
+		radius, radiusErr = strconv.ParseFloat(v, 64)
+		if unit == "mi" {
+			radius *= geo.KmPerMile
+		}
+	}
+	limit, clamped := h.limit(c, "nearby")
+	offset, offsetErr := strconv.Atoi(c.DefaultQuery("offset", "0"))
 
 	// Basic validation
 	if latErr != nil || lonErr != nil || radiusErr != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid or missing lat/lon/radius parameters"})
+		errorResponse(c, http.StatusBadRequest, CodeValidation, "invalid or missing lat/lon/radius parameters")
+		return
+	}
+	// ParseFloat accepts "NaN" and "Inf", which slip past range comparisons
+	if !isFinite(lat) || !isFinite(lon) || !isFinite(radius) ||
+		math.Abs(lat) > 90 || math.Abs(lon) > 180 || radius <= 0 {
+		errorResponse(c, http.StatusBadRequest, CodeValidation, "invalid lat/lon/radius values")
+		return
+	}
+	radiusClamped := false
+	if radius > h.maxRadius {
+		radius = h.maxRadius
+		radiusClamped = true
+	}
+	if offsetErr != nil || offset < 0 || offset > maxNearbyOffset {
+		errorResponse(c, http.StatusBadRequest, CodeValidation, "offset must be an integer between 0 and "+strconv.Itoa(maxNearbyOffset))
+		return
+	}
+	fields, ok := parseFields(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := h.requestContext(c)
+	defer cancel()
+	results, partial, err := h.svc.Nearby(ctx, lat, lon, radius, limit, offset, fields)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, CodeInternal, err.Error())
+		return
+	}
+
+	// results are ordered by distance, so the last one is the farthest
+	maxDistance := 0.0
+	if len(results) > 0 && results[len(results)-1].DistanceKm != nil {
+		maxDistance = *results[len(results)-1].DistanceKm
+	}
+	radiusOut, maxDistanceOut := radius, maxDistance
+	if unit == "mi" {
+		radiusOut, maxDistanceOut = radius/geo.KmPerMile, maxDistance/geo.KmPerMile
+		for _, a := range results {
+			if a.DistanceKm != nil {
+				mi := *a.DistanceKm / geo.KmPerMile
+				a.DistanceMi, a.DistanceKm = &mi, nil
+			}
+		}
+	}
+	data, err := project(results, fields, "distance_"+unit)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, CodeInternal, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"meta": gin.H{
+			"count":           len(results),
+			"unit":            unit,
+			"radius":          radiusOut,
+			"radius_km":       radius,
+			"radius_clamped":  radiusClamped,
+			"limit":           limit,
+			"limit_clamped":   clamped,
+			"offset":          offset,
+			"max_distance":    maxDistanceOut,
+			"max_distance_km": maxDistance,
+			"partial":         partial,
+		},
+		"data": data,
+	})
+}
+
+// BoundingBox: GET /v1/news/bbox?min_lat=12.8&min_lon=77.4&max_lat=13.1&max_lon=77.8&limit=50
+// Returns articles inside a map viewport. min_lon > max_lon is treated as a box
+// crossing the antimeridian (e.g. min_lon=170&max_lon=-170).
+func (h *Handler) BoundingBox(c *gin.Context) {
+	q := c.Request.URL.Query()
+
+	minLat, minLatErr := strconv.ParseFloat(q.Get("min_lat"), 64)
+	minLon, minLonErr := strconv.ParseFloat(q.Get("min_lon"), 64)
+	maxLat, maxLatErr := strconv.ParseFloat(q.Get("max_lat"), 64)
+	maxLon, maxLonErr := strconv.ParseFloat(q.Get("max_lon"), 64)
+	limit, clamped := h.limit(c, "bbox")
+
+	if minLatErr != nil || minLonErr != nil || maxLatErr != nil || maxLonErr != nil {
+		errorResponse(c, http.StatusBadRequest, CodeValidation, "invalid or missing min_lat/min_lon/max_lat/max_lon parameters")
 		return
 	}
-	if math.Abs(lat) > 90 || math.Abs(lon) > 180 || radius <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid lat/lon/radius values"})
+	// ParseFloat accepts "NaN" and "Inf", which slip past range comparisons
+	if !isFinite(minLat) || !isFinite(minLon) || !isFinite(maxLat) || !isFinite(maxLon) {
+		errorResponse(c, http.StatusBadRequest, CodeValidation, "bounding box coordinates must be finite numbers")
+		return
+	}
+	if math.Abs(minLat) > 90 || math.Abs(maxLat) > 90 || math.Abs(minLon) > 180 || math.Abs(maxLon) > 180 {
+		errorResponse(c, http.StatusBadRequest, CodeValidation, "bounding box is outside world bounds")
+		return
+	}
+	if minLat >= maxLat || minLon == maxLon {
+		errorResponse(c, http.StatusBadRequest, CodeValidation, "min_lat must be less than max_lat and min_lon must differ from max_lon")
 		return
 	}
 
-	results, err := h.svc.Nearby(c.Request.Context(), lat, lon, radius, limit)
+	ctx, cancel := h.requestContext(c)
+	defer cancel()
+	results, err := h.svc.InBoundingBox(ctx, minLat, minLon, maxLat, maxLon, limit)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		errorResponse(c, http.StatusInternalServerError, CodeInternal, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"meta": gin.H{
+			"count":                len(results),
+			"limit":                limit,
+			"limit_clamped":        clamped,
+			"crosses_antimeridian": minLon > maxLon,
+		},
+		"data": results,
+	})
+}
+
+// defaultArchiveWindow is how far back /v1/news/archive looks when from is omitted.
+const defaultArchiveWindow = 30 * 24 * time.Hour
+
+// maxRecentHours caps the recent window at 30 days.
+const maxRecentHours = 720
+
+// Recent: GET /v1/news/recent?hours=24&limit=50
+// Returns articles published in the last hours (default 24), newest first.
+// Unlike trending, relevance plays no part in the order.
+func (h *Handler) Recent(c *gin.Context) {
+	hours, err := strconv.Atoi(c.DefaultQuery("hours", "24"))
+	if err != nil || hours < 1 || hours > maxRecentHours {
+		errorResponse(c, http.StatusBadRequest, CodeValidation, "hours must be an integer between 1 and "+strconv.Itoa(maxRecentHours))
+		return
+	}
+	limit, clamped := h.limit(c, "recent")
+	ctx, cancel := h.requestContext(c)
+	defer cancel()
+	results, err := h.svc.Recent(ctx, time.Duration(hours)*time.Hour, limit)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, CodeInternal, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"meta": gin.H{
+			"count":         len(results),
+			"hours":         hours,
+			"limit":         limit,
+			"limit_clamped": clamped,
+		},
+		"data": results,
+	})
+}
+
+// Archive: GET /v1/news/archive?from=2024-01-01&to=2024-01-31T23:59:59Z&limit=50&offset=0
+// Returns articles published in [from, to], oldest first. to defaults to now and
+// from to 30 days before to. Both accept RFC 3339 timestamps or YYYY-MM-DD dates.
+func (h *Handler) Archive(c *gin.Context) {
+	to := time.Now().UTC()
+	if v := c.Query("to"); v != "" {
+		t, ok := parseEndTime(v)
+		if !ok {
+			errorResponse(c, http.StatusBadRequest, CodeValidation, "invalid to: use RFC 3339 or YYYY-MM-DD")
+			return
+		}
+		to = t
+	}
+	from := to.Add(-defaultArchiveWindow)
+	if v := c.Query("from"); v != "" {
+		t, ok := parseTime(v)
+		if !ok {
+			errorResponse(c, http.StatusBadRequest, CodeValidation, "invalid from: use RFC 3339 or YYYY-MM-DD")
+			return
+		}
+		from = t
+	}
+	if from.After(to) {
+		errorResponse(c, http.StatusBadRequest, CodeValidation, "from must not be after to")
+		return
+	}
+	limit, clamped := h.limit(c, "archive")
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 || offset > maxPageOffset {
+		errorResponse(c, http.StatusBadRequest, CodeValidation, "offset must be an integer between 0 and "+strconv.Itoa(maxPageOffset))
 		return
 	}
 
+	ctx, cancel := h.requestContext(c)
+	defer cancel()
+	results, err := h.svc.Archive(ctx, from, to, limit, offset)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, CodeInternal, err.Error())
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{
 		"meta": gin.H{
-			"count":     len(results),
-			"radius_km": radius,
-			"limit":     limit,
+			"count":         len(results),
+			"from":          from,
+			"to":            to,
+			"limit":         limit,
+			"limit_clamped": clamped,
+			"offset":        offset,
 		},
 		"data": results,
 	})
 }
 
-// GenerateSummary: POST /v1/news/:id/summary
+// Similar: GET /v1/news/similar/:id?limit=10
+// Returns other articles sharing at least one category, most shared first; 404 if id doesn't exist.
+func (h *Handler) Similar(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	limit, clamped := h.limit(c, "similar")
+	ctx, cancel := h.requestContext(c)
+	defer cancel()
+	results, err := h.svc.Similar(ctx, id, limit)
+	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			errorResponse(c, http.StatusNotFound, CodeNotFound, err.Error())
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, CodeInternal, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"meta": gin.H{"count": len(results), "limit": limit, "limit_clamped": clamped, "id": id},
+		"data": results,
+	})
+}
+
+// GetArticle: GET /v1/news/:id
+// Returns the full article record.
+func (h *Handler) GetArticle(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	ctx, cancel := h.requestContext(c)
+	defer cancel()
+	art, err := h.svc.GetArticle(ctx, id)
+	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			errorResponse(c, http.StatusNotFound, CodeNotFound, err.Error())
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, CodeInternal, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, art)
+}
+
+// UpdateArticle: PUT /v1/news/:id
+// Body: full article JSON. Replaces the mutable fields and returns the updated record.
+// The body id may be omitted but must match the path id when present.
+// llm_summary is ignored (use the summary endpoints) and an empty or omitted
+// content keeps the stored body.
+func (h *Handler) UpdateArticle(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	var art models.Article
+	if err := c.ShouldBindJSON(&art); err != nil {
+		errorResponse(c, http.StatusBadRequest, CodeValidation, "invalid json: "+err.Error())
+		return
+	}
+	if art.ID != "" && art.ID != id {
+		errorResponse(c, http.StatusBadRequest, CodeValidation, "id in body does not match id in path")
+		return
+	}
+	art.ID = id
+	ctx, cancel := h.requestContext(c)
+	defer cancel()
+	updated, err := h.svc.UpdateArticle(ctx, &art)
+	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			errorResponse(c, http.StatusNotFound, CodeNotFound, err.Error())
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, CodeInternal, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, updated)
+}
+
+// UpdateGeo: PUT /v1/news/:id/geo
+// Body: {"latitude": 12.97, "longitude": 77.59}. Sets the article's coordinates
+// (for geocoding workers) and returns the updated record.
+func (h *Handler) UpdateGeo(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	var body struct {
+		Latitude  *float64 `json:"latitude"`
+		Longitude *float64 `json:"longitude"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		errorResponse(c, http.StatusBadRequest, CodeValidation, "invalid json: "+err.Error())
+		return
+	}
+	if body.Latitude == nil || body.Longitude == nil {
+		errorResponse(c, http.StatusBadRequest, CodeValidation, "latitude and longitude are required")
+		return
+	}
+	lat, lon := *body.Latitude, *body.Longitude
+	if !isFinite(lat) || !isFinite(lon) || math.Abs(lat) > 90 || math.Abs(lon) > 180 {
+		errorResponse(c, http.StatusBadRequest, CodeValidation, "latitude must be between -90 and 90 and longitude between -180 and 180")
+		return
+	}
+	ctx, cancel := h.requestContext(c)
+	defer cancel()
+	updated, err := h.svc.UpdateGeo(ctx, id, lat, lon)
+	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			errorResponse(c, http.StatusNotFound, CodeNotFound, err.Error())
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, CodeInternal, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, updated)
+}
+
+// DeleteArticle: DELETE /v1/news/:id
+// Soft-deletes the article (see /v1/admin/deleted). Returns 204 on success and 404 if the article doesn't exist.
+func (h *Handler) DeleteArticle(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	ctx, cancel := h.requestContext(c)
+	defer cancel()
+	if err := h.svc.DeleteArticle(ctx, id); err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			errorResponse(c, http.StatusNotFound, CodeNotFound, err.Error())
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, CodeInternal, err.Error())
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// BulkDelete: POST /v1/news/bulk-delete
+// Body: {"ids": ["...", ...]}. Soft-deletes the articles and returns how many were deleted.
+func (h *Handler) BulkDelete(c *gin.Context) {
+	var req struct {
+		IDs []string `json:"ids"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errorResponse(c, http.StatusBadRequest, CodeValidation, "invalid json: "+err.Error())
+		return
+	}
+	ctx, cancel := h.requestContext(c)
+	defer cancel()
+	n, err := h.svc.DeleteArticles(ctx, req.IDs)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidBulkDelete) {
+			errorResponse(c, http.StatusBadRequest, CodeValidation, err.Error())
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, CodeInternal, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"deleted": n, "requested": len(req.IDs)})
+}
+
+// GenerateSummary: POST /v1/news/:id/summary?async=true
 // Triggers LLM summarization, saves summary to DB and returns it.
+// With async=true the job is queued instead and 202 is returned with a job id;
+// poll GET /v1/news/:id/summary for the result.
+// ?model= picks a model from LLM_ALLOWED_MODELS instead of the default and
+// ?length=short|medium|long the summary length (default medium).
+// With debug=true nothing is saved; the response adds the raw LLM body, the parse
+// branch that matched, and the prompt and model sent.
+// An article that already has a summary gets it back (cached: true) without an
+// LLM call, also with async=true, unless force=true.
 func (h *Handler) GenerateSummary(c *gin.Context) {
-	id := c.Param("id")
-	if id == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "missing id parameter"})
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	async, err := strconv.ParseBool(c.DefaultQuery("async", "false"))
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, CodeValidation, "invalid async value")
 		return
 	}
+	debug, err := strconv.ParseBool(c.DefaultQuery("debug", "false"))
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, CodeValidation, "invalid debug value")
+		return
+	}
+	if debug && async {
+		errorResponse(c, http.StatusBadRequest, CodeValidation, "debug and async can't be combined")
+		return
+	}
+	force, err := strconv.ParseBool(c.DefaultQuery("force", "false"))
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, CodeValidation, "invalid force value")
+		return
+	}
+	opts := service.SummaryOptions{
+		Model:  c.Query("model"),
+		Length: c.Query("length"),
+		Force:  force,
+	}
 	ctx := c.Request.Context()
 
-	summary, err := h.svc.SummarizeArticle(ctx, id)
+	if debug {
+		res, err := h.svc.DebugSummary(ctx, id, opts)
+		if err != nil {
+			switch {
+			case errors.Is(err, service.ErrNotFound):
+				errorResponse(c, http.StatusNotFound, CodeNotFound, err.Error())
+			case errors.Is(err, service.ErrModelNotAllowed), errors.Is(err, service.ErrInvalidLength):
+				errorResponse(c, http.StatusBadRequest, CodeValidation, err.Error())
+			case errors.Is(err, service.ErrLLMBusy):
+				errorResponse(c, http.StatusServiceUnavailable, CodeUnavailable, err.Error())
+			default:
+				errorResponse(c, http.StatusInternalServerError, CodeInternal, err.Error(), gin.H{"debug": res})
+			}
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"id":      id,
+			"summary": res.Summary,
+			"debug":   res,
+		})
+		return
+	}
+
+	if async {
+		jobID, existing, err := h.svc.EnqueueSummary(ctx, id, opts)
+		if err != nil {
+			switch {
+			case errors.Is(err, service.ErrModelNotAllowed), errors.Is(err, service.ErrInvalidLength):
+				errorResponse(c, http.StatusBadRequest, CodeValidation, err.Error())
+			case errors.Is(err, service.ErrNotFound):
+				errorResponse(c, http.StatusNotFound, CodeNotFound, err.Error())
+			case errors.Is(err, service.ErrQueueUnavailable):
+				errorResponse(c, http.StatusServiceUnavailable, CodeUnavailable, err.Error())
+			default:
+				errorResponse(c, http.StatusInternalServerError, CodeInternal, err.Error())
+			}
+			return
+		}
+		if jobID == "" {
+			c.JSON(http.StatusOK, gin.H{
+				"id":      id,
+				"summary": existing,
+				"cached":  true,
+				"status":  service.SummaryDone,
+			})
+			return
+		}
+		c.JSON(http.StatusAccepted, gin.H{
+			"id":     id,
+			"job_id": jobID,
+			"status": service.SummaryPending,
+		})
+		return
+	}
+
+	summary, cached, model, err := h.svc.SummarizeArticle(ctx, id, opts)
 	if err != nil {
-		// map known errors to proper status codes if you want (e.g., not found)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		switch {
+		case errors.Is(err, service.ErrNotFound):
+			errorResponse(c, http.StatusNotFound, CodeNotFound, err.Error())
+		case errors.Is(err, service.ErrModelNotAllowed), errors.Is(err, service.ErrInvalidLength):
+			errorResponse(c, http.StatusBadRequest, CodeValidation, err.Error())
+		case errors.Is(err, service.ErrLLMBusy):
+			errorResponse(c, http.StatusServiceUnavailable, CodeUnavailable, err.Error())
+		default:
+			errorResponse(c, http.StatusInternalServerError, CodeInternal, err.Error())
+		}
 		return
 	}
 
+	resp := gin.H{
+		"id":      id,
+		"summary": summary,
+		"cached":  cached,
+	}
+	if model != "" {
+		resp["model"] = model
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetSummary: GET /v1/news/:id/summary
+// Returns the stored summary and its status: "pending"/"failed" for a queued job,
+// otherwise "done" or "none".
+func (h *Handler) GetSummary(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	ctx, cancel := h.requestContext(c)
+	defer cancel()
+	summary, status, err := h.svc.SummaryStatus(ctx, id)
+	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			errorResponse(c, http.StatusNotFound, CodeNotFound, err.Error())
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, CodeInternal, err.Error())
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{
 		"id":      id,
+		"status":  status,
 		"summary": summary,
 	})
 }
 
-// parseLimit ensures a sane integer limit, with bounds
-func parseLimit(s string) int {
-	l, err := strconv.Atoi(s)
-	if err != nil || l <= 0 {
-		return 10
+// GenerateSummaryBatch: POST /v1/news/summary/batch
+// Body: {"ids": ["...", "..."]}
+// Summarizes all articles concurrently; at most the "summary_batch" limit of ids
+// (LIMIT_MAX_SUMMARY_BATCH) per request. Responds 200 when every id succeeded,
+// 207 when some failed; the body always lists summaries and failed ids.
+func (h *Handler) GenerateSummaryBatch(c *gin.Context) {
+	var body struct {
+		IDs []string `json:"ids"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		errorResponse(c, http.StatusBadRequest, CodeValidation, "invalid json: "+err.Error())
+		return
+	}
+	if len(body.IDs) == 0 {
+		errorResponse(c, http.StatusBadRequest, CodeValidation, "ids must not be empty")
+		return
+	}
+	if max := h.limits["summary_batch"].Max; len(body.IDs) > max {
+		errorResponse(c, http.StatusBadRequest, CodeValidation, "ids must not contain more than "+strconv.Itoa(max)+" entries")
+		return
+	}
+
+	summaries, failed, err := h.svc.SummarizeBatch(c.Request.Context(), body.IDs)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, CodeInternal, err.Error())
+		return
+	}
+
+	status := http.StatusOK
+	if len(failed) > 0 {
+		status = http.StatusMultiStatus
+	}
+	c.JSON(status, gin.H{
+		"meta": gin.H{
+			"requested": len(body.IDs),
+			"succeeded": len(summaries),
+			"failed":    len(failed),
+		},
+		"summaries": summaries,
+		"failed":    failed,
+	})
+}
+
+// RecomputeRelevance: POST /v1/admin/recompute-relevance
+// Re-applies time decay to relevance scores so trending stays fresh.
+func (h *Handler) RecomputeRelevance(c *gin.Context) {
+	ctx, cancel := h.requestContext(c)
+	defer cancel()
+	n, err := h.svc.RecomputeRelevance(ctx)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, CodeInternal, err.Error())
+		return
 	}
-	if l > 200 {
-		return 200
+	c.JSON(http.StatusOK, gin.H{
+		"meta": gin.H{"updated": n},
+	})
+}
+
+// defaultReindexBatches and maxReindexBatches bound how many batches one
+// reindex request works through (?max_batches=).
+const (
+	defaultReindexBatches = 20
+	maxReindexBatches     = 1000
+)
+
+// Reindex: POST /v1/news/reindex?after=<id>&limit=500&max_batches=20&embeddings=false
+// Recomputes derived columns (search_vector, and embeddings with embeddings=true)
+// for articles after the cursor, limit per batch. Pass meta.next_after back as
+// ?after= until meta.done is true. Admin access applies as for /v1/admin.
+func (h *Handler) Reindex(c *gin.Context) {
+	limit, clamped := h.limit(c, "reindex")
+	after := c.Query("after")
+	maxBatches, err := strconv.Atoi(c.DefaultQuery("max_batches", strconv.Itoa(defaultReindexBatches)))
+	if err != nil || maxBatches <= 0 || maxBatches > maxReindexBatches {
+		errorResponse(c, http.StatusBadRequest, CodeValidation, "max_batches must be an integer between 1 and "+strconv.Itoa(maxReindexBatches))
+		return
+	}
+	embeddings, err := strconv.ParseBool(c.DefaultQuery("embeddings", "false"))
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, CodeValidation, "invalid embeddings value")
+		return
+	}
+	ctx, cancel := h.requestContext(c)
+	defer cancel()
+	rep, err := h.svc.Reindex(ctx, after, limit, maxBatches, embeddings)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidID):
+			errorResponse(c, http.StatusBadRequest, CodeValidation, "invalid after value")
+		case errors.Is(err, service.ErrEmbeddingsDisabled):
+			errorResponse(c, http.StatusNotImplemented, CodeNotImplemented, err.Error())
+		default:
+			// batches before the failure are committed; report where to resume
+			errorResponse(c, http.StatusInternalServerError, CodeInternal, err.Error(), gin.H{"meta": rep})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"meta": gin.H{
+			"batches":       rep.Batches,
+			"reindexed":     rep.Reindexed,
+			"embedded":      rep.Embedded,
+			"embed_failed":  rep.EmbedFailed,
+			"next_after":    rep.NextAfter,
+			"done":          rep.Done,
+			"limit":         limit,
+			"limit_clamped": clamped,
+		},
+	})
+}
+
+// DeletedArticles: GET /v1/admin/deleted?limit=50&offset=0
+// Lists soft-deleted articles, most recently deleted first.
+func (h *Handler) DeletedArticles(c *gin.Context) {
+	limit, clamped := h.limit(c, "deleted")
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		errorResponse(c, http.StatusBadRequest, CodeValidation, "offset must be a non-negative integer")
+		return
+	}
+	ctx, cancel := h.requestContext(c)
+	defer cancel()
+	results, err := h.svc.DeletedArticles(ctx, limit, offset)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, CodeInternal, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"meta": gin.H{"count": len(results), "limit": limit, "limit_clamped": clamped, "offset": offset},
+		"data": results,
+	})
+}
+
+// Duplicates: GET /v1/admin/duplicates?limit=100
+// Lists URLs shared by several live articles with their ids, newest first, so
+// the older copies (ids[1:]) can be removed with POST /v1/news/bulk-delete.
+func (h *Handler) Duplicates(c *gin.Context) {
+	limit, clamped := h.limit(c, "duplicates")
+	ctx, cancel := h.requestContext(c)
+	defer cancel()
+	dups, err := h.svc.DuplicateURLs(ctx, limit)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, CodeInternal, err.Error())
+		return
+	}
+	extra := 0
+	for _, d := range dups {
+		extra += d.Count - 1
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"meta": gin.H{
+			"count":         len(dups),
+			"duplicates":    extra,
+			"limit":         limit,
+			"limit_clamped": clamped,
+		},
+		"data": dups,
+	})
+}
+
+// RestoreArticle: POST /v1/admin/news/:id/restore
+// Undoes a soft delete. Returns 204 on success and 404 if no deleted article has that id.
+func (h *Handler) RestoreArticle(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	ctx, cancel := h.requestContext(c)
+	defer cancel()
+	if err := h.svc.RestoreArticle(ctx, id); err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			errorResponse(c, http.StatusNotFound, CodeNotFound, err.Error())
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, CodeInternal, err.Error())
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// SchemaStatus: GET /v1/admin/schema
+// Lists applied and pending schema migrations.
+func (h *Handler) SchemaStatus(c *gin.Context) {
+	ctx, cancel := h.requestContext(c)
+	defer cancel()
+	st, err := h.svc.SchemaStatus(ctx)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, CodeInternal, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": st})
+}
+
+// SearchWeights: GET /v1/admin/search-weights
+// Reports the source weights multiplied into the search rank (SEARCH_SOURCE_WEIGHTS),
+// for tuning; sources not listed use default_weight.
+func (h *Handler) SearchWeights(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"default_weight": 1.0,
+			"weights":        h.svc.SearchSourceWeights(),
+		},
+	})
+}
+
+// Migrate: POST /v1/admin/migrate
+// Runs pending schema migrations. Not bound by the request timeout: a
+// migration cut off half-way would just be rolled back and retried.
+func (h *Handler) Migrate(c *gin.Context) {
+	applied, err := h.svc.Migrate(c.Request.Context())
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, CodeInternal, err.Error(), gin.H{
+			"meta": gin.H{"applied": len(applied)},
+			"data": applied,
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"meta": gin.H{"applied": len(applied)},
+		"data": applied,
+	})
+}
+
+// parseID reads the :id path parameter and rejects non-UUID values with 400
+// before they reach Postgres' uuid cast.
+func parseID(c *gin.Context) (string, bool) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		errorResponse(c, http.StatusBadRequest, CodeValidation, service.ErrInvalidID.Error())
+		return "", false
+	}
+	return id, true
+}
+
+// isFinite reports whether f is neither NaN nor infinite.
+func isFinite(f float64) bool {
+	return !math.IsNaN(f) && !math.IsInf(f, 0)
+}
+
+// parseTime accepts an RFC 3339 timestamp or a YYYY-MM-DD date (midnight UTC).
+func parseTime(s string) (time.Time, bool) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, true
+	}
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+// parseEndTime is parseTime for the upper bound of a range: a YYYY-MM-DD date
+// means the end of that day, so to=2024-01-31 still includes Jan 31.
+func parseEndTime(s string) (time.Time, bool) {
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		// the last microsecond, the finest timestamp Postgres stores
+		return t.Add(24*time.Hour - time.Microsecond), true
+	}
+	return parseTime(s)
+}
+
+// parseSort validates the optional sort query param against the allowed values.
+// On an unknown value it writes a 400 response and returns false.
+func parseSort(c *gin.Context) (models.SortOrder, bool) {
+	sort := models.SortOrder(c.Query("sort"))
+	if !sort.Valid() {
+		errorResponse(c, http.StatusBadRequest, CodeValidation, "invalid sort: must be one of published_desc, published_asc, relevance_desc, title_asc")
+		return "", false
+	}
+	return sort, true
+}
+
+// parseFilter reads the optional filters shared by the listing endpoints.
+// An empty or missing source, lang or min_relevance applies no filter for it.
+// min_relevance is on the relevance_score scale: server scoring yields 0-1, though
+// source weights or client-supplied scores can exceed 1, so only negatives are
+// rejected. On an invalid value it writes a 400 response and returns false.
+func parseFilter(c *gin.Context) (models.ArticleFilter, bool) {
+	f := models.ArticleFilter{
+		Sources:  splitCSV(c.Query("source")),
+		Language: strings.TrimSpace(c.Query("lang")),
+	}
+	if raw := strings.TrimSpace(c.Query("min_relevance")); raw != "" {
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil || !isFinite(v) || v < 0 {
+			errorResponse(c, http.StatusBadRequest, CodeValidation, "min_relevance must be a non-negative number")
+			return models.ArticleFilter{}, false
+		}
+		f.MinRelevance = &v
+	}
+	return f, true
+}
+
+// splitCSV splits a comma-separated value, trimming spaces and dropping empty items.
+func splitCSV(s string) []string {
+	out := []string{}
+	for _, part := range strings.Split(s, ",") {
+		if p := strings.TrimSpace(part); p != "" {
+			out = append(out, p)
+		}
 	}
-	return l
+	return out
 }
@@ -0,0 +1,225 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/nitesh/news_service/internal/service"
+	"github.com/nitesh/news_service/pkg/models"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// serve runs handler on a request for method, target and body and returns the
+// recorded response. The handlers exercised here reject the request before
+// touching the service, so h may have a nil service.
+func serve(handler gin.HandlerFunc, method, target, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(method, target, strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	handler(c)
+	return w
+}
+
+// idsJSON returns a {"ids": [...]} body with n ids.
+func idsJSON(n int) string {
+	ids := make([]string, n)
+	for i := range ids {
+		ids[i] = `"00000000-0000-0000-0000-000000000000"`
+	}
+	return `{"ids":[` + strings.Join(ids, ",") + `]}`
+}
+
+func TestGenerateSummaryBatchValidation(t *testing.T) {
+	h := NewHandler(nil)
+	h.SetMaxLimit("summary_batch", 3)
+
+	tests := []struct {
+		name    string
+		body    string
+		wantMsg string
+	}{
+		{"invalid json", `{"ids":`, "invalid json"},
+		{"no ids", `{"ids":[]}`, "ids must not be empty"},
+		{"too many ids", idsJSON(4), "ids must not contain more than 3 entries"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(h.GenerateSummaryBatch, http.MethodPost, "/v1/news/summary/batch", tt.body)
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want 400 (body %s)", w.Code, w.Body)
+			}
+			if !strings.Contains(w.Body.String(), tt.wantMsg) {
+				t.Errorf("body %s does not mention %q", w.Body, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestBoundingBoxValidation(t *testing.T) {
+	h := NewHandler(nil)
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{"missing", "min_lat=1&min_lon=1&max_lat=2"},
+		{"not a number", "min_lat=x&min_lon=1&max_lat=2&max_lon=2"},
+		{"NaN latitude", "min_lat=NaN&min_lon=1&max_lat=2&max_lon=2"},
+		{"NaN longitude", "min_lat=1&min_lon=1&max_lat=2&max_lon=nan"},
+		{"infinite", "min_lat=1&min_lon=-Inf&max_lat=2&max_lon=2"},
+		{"outside the world", "min_lat=1&min_lon=1&max_lat=91&max_lon=2"},
+		{"inverted latitudes", "min_lat=2&min_lon=1&max_lat=1&max_lon=2"},
+		{"zero width", "min_lat=1&min_lon=1&max_lat=2&max_lon=1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(h.BoundingBox, http.MethodGet, "/v1/news/bbox?"+tt.query, "")
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want 400 (body %s)", w.Code, w.Body)
+			}
+		})
+	}
+}
+
+// rangeStore records the time range the date-windowed endpoints query.
+type rangeStore struct {
+	service.ArticleStore
+	from, to time.Time
+	offset   int
+}
+
+func (r *rangeStore) ExportPage(ctx context.Context, from, to time.Time, after string, limit int) ([]*models.Article, error) {
+	r.from, r.to = from, to
+	return []*models.Article{}, nil
+}
+
+func (r *rangeStore) ArchiveRange(ctx context.Context, from, to time.Time, limit, offset int) ([]*models.Article, error) {
+	r.from, r.to, r.offset = from, to, offset
+	return []*models.Article{}, nil
+}
+
+func TestArchiveRange(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		wantFrom string
+		wantTo   string
+	}{
+		{"single day", "from=2024-03-01&to=2024-03-01", "2024-03-01T00:00:00Z", "2024-03-01T23:59:59.999999Z"},
+		{"date-only to covers its whole day", "from=2024-01-01&to=2024-01-31", "2024-01-01T00:00:00Z", "2024-01-31T23:59:59.999999Z"},
+		{"timestamps are exact", "from=2024-01-01T06:00:00Z&to=2024-01-31T12:00:00Z", "2024-01-01T06:00:00Z", "2024-01-31T12:00:00Z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &rangeStore{}
+			w := serve(NewHandler(service.NewService(repo, nil, nil)).Archive, http.MethodGet, "/v1/news/archive?"+tt.query, "")
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200 (body %s)", w.Code, w.Body)
+			}
+			if got := repo.from.Format(time.RFC3339Nano); got != tt.wantFrom {
+				t.Errorf("from = %s, want %s", got, tt.wantFrom)
+			}
+			if got := repo.to.Format(time.RFC3339Nano); got != tt.wantTo {
+				t.Errorf("to = %s, want %s", got, tt.wantTo)
+			}
+		})
+	}
+}
+
+func TestExportRange(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		wantFrom string
+		wantTo   string
+	}{
+		{"documented example", "from=2024-01-01&to=2024-01-31", "2024-01-01T00:00:00Z", "2024-01-31T23:59:59.999999Z"},
+		{"timestamps are exact", "from=2024-01-01T06:00:00Z&to=2024-01-31T12:00:00Z", "2024-01-01T06:00:00Z", "2024-01-31T12:00:00Z"},
+		{"open ended", "", "0001-01-01T00:00:00Z", "0001-01-01T00:00:00Z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &rangeStore{}
+			w := serve(NewHandler(service.NewService(repo, nil, nil)).Export, http.MethodGet, "/v1/news/export?format=json&"+tt.query, "")
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200 (body %s)", w.Code, w.Body)
+			}
+			if got := repo.from.Format(time.RFC3339Nano); got != tt.wantFrom {
+				t.Errorf("from = %s, want %s", got, tt.wantFrom)
+			}
+			if got := repo.to.Format(time.RFC3339Nano); got != tt.wantTo {
+				t.Errorf("to = %s, want %s", got, tt.wantTo)
+			}
+		})
+	}
+}
+
+func TestArchiveValidation(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{"bad date", "to=2024-02-30"},
+		{"from after to", "from=2024-03-02&to=2024-03-01"},
+		{"negative offset", "offset=-1"},
+		{"offset too deep", "offset=10001"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(NewHandler(nil).Archive, http.MethodGet, "/v1/news/archive?"+tt.query, "")
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want 400 (body %s)", w.Code, w.Body)
+			}
+		})
+	}
+}
+
+// TestInvalidJSONEnvelope checks every JSON body handler answers malformed
+// input with the JSON error envelope, not a bare 400 from gin's BindJSON.
+func TestInvalidJSONEnvelope(t *testing.T) {
+	h := NewHandler(nil)
+	r := gin.New()
+	r.PUT("/v1/news/:id", h.UpdateArticle)
+	r.PUT("/v1/news/:id/geo", h.UpdateGeo)
+	r.POST("/v1/news/summary/batch", h.GenerateSummaryBatch)
+	const id = "00000000-0000-0000-0000-000000000000"
+
+	tests := []struct {
+		method, target string
+	}{
+		{http.MethodPut, "/v1/news/" + id},
+		{http.MethodPut, "/v1/news/" + id + "/geo"},
+		{http.MethodPost, "/v1/news/summary/batch"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(`{"title":`))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want 400 (body %s)", w.Code, w.Body)
+			}
+			// Result has the headers as sent; Header() shows later changes too
+			if ct := w.Result().Header.Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+				t.Errorf("Content-Type = %q, want application/json", ct)
+			}
+			var env struct {
+				Code string `json:"code"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &env); err != nil || env.Code != CodeValidation {
+				t.Errorf("body %s is not a %s envelope (%v)", w.Body, CodeValidation, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/nitesh/news_service/internal/store"
+)
+
+// LimitConfig is the page size used when a request omits ?limit= (Default)
+// and the largest page size a request may ask for (Max).
+type LimitConfig struct {
+	Default int
+	Max     int
+}
+
+// DefaultLimits lists the default and maximum ?limit= of every paginated endpoint.
+// This is the one place they are defined; maxima can be overridden per endpoint
+// with SetMaxLimit (main reads LIMIT_MAX_<NAME>, e.g. LIMIT_MAX_SEARCH=50).
+// No maximum may exceed store.MaxQueryRows, the most rows a query returns.
+// A Default of 0 means "return everything" (up to Max).
+var DefaultLimits = map[string]LimitConfig{
+	"search":          {Default: 10, Max: 100},
+	"semantic_search": {Default: 10, Max: 100},
+	"category":        {Default: 10, Max: 100},
+	"trending":        {Default: 10, Max: 100},
+	"similar":         {Default: 10, Max: 100},
+	"nearby":          {Default: 20, Max: 200},
+	"bbox":            {Default: 50, Max: 200},
+	"archive":         {Default: 50, Max: 200},
+	"recent":          {Default: 50, Max: 200},
+	"deleted":         {Default: 50, Max: 200},
+	"unsummarized":    {Default: 50, Max: 500},
+	"ungeocoded":      {Default: 50, Max: 500},
+	"duplicates":      {Default: 100, Max: 1000},
+	"reindex":         {Default: 500, Max: 1000},
+	"sources":         {Default: 0, Max: 1000},
+	"categories":      {Default: 0, Max: 1000},
+	"summary_batch":   {Default: 100, Max: 100}, // ids per POST /v1/news/summary/batch, not a page size
+}
+
+// SetMaxLimit overrides the maximum ?limit= for one endpoint of DefaultLimits.
+// Unknown endpoints and non-positive values are ignored; values above
+// store.MaxQueryRows are reduced to it, as the store wouldn't return more rows
+// and cursor endpoints would mistake the short page for the last one.
+func (h *Handler) SetMaxLimit(endpoint string, max int) {
+	cfg, ok := h.limits[endpoint]
+	if !ok || max <= 0 {
+		return
+	}
+	cfg.Max = min(max, store.MaxQueryRows)
+	if cfg.Default > cfg.Max {
+		cfg.Default = cfg.Max
+	}
+	h.limits[endpoint] = cfg
+}
+
+// clampLimit returns def for a missing/non-positive request and caps it at max.
+func clampLimit(requested, max, def int) int {
+	if requested <= 0 {
+		return def
+	}
+	if requested > max {
+		return max
+	}
+	return requested
+}
+
+// limit reads ?limit= for endpoint and returns the effective limit and whether
+// the requested value was reduced to the endpoint's maximum. Missing or invalid
+// values use the endpoint default.
+func (h *Handler) limit(c *gin.Context, endpoint string) (int, bool) {
+	cfg := h.limits[endpoint]
+	requested, err := strconv.Atoi(c.Query("limit"))
+	if err != nil {
+		requested = 0
+	}
+	eff := clampLimit(requested, cfg.Max, cfg.Default)
+	return eff, requested > cfg.Max
+}
+
+// copyLimits returns a copy of DefaultLimits so handlers can override maxima independently.
+func copyLimits() map[string]LimitConfig {
+	out := make(map[string]LimitConfig, len(DefaultLimits))
+	for k, v := range DefaultLimits {
+		out[k] = v
+	}
+	return out
+}
+
+// maxPageOffset bounds how deep page-number paging may go (OFFSET scans every
+// skipped row); deeper results need cursor pagination.
+const maxPageOffset = 10000
+
+// pageParams is the result of parsing ?page= and ?page_size=.
+type pageParams struct {
+	Page    int // 1-based; 0 when page-number paging wasn't requested
+	Size    int
+	Clamped bool
+}
+
+// offset returns the number of rows skipped before the page.
+func (p pageParams) offset() int {
+	if p.Page <= 1 {
+		return 0
+	}
+	return (p.Page - 1) * p.Size
+}
+
+// totalPages returns the number of pages needed for total rows.
+func (p pageParams) totalPages(total int) int {
+	if p.Size <= 0 {
+		return 0
+	}
+	return (total + p.Size - 1) / p.Size
+}
+
+// page reads ?page= and ?page_size= for endpoint. Page-number paging is used
+// when either is present: page defaults to 1 and page_size to the endpoint's
+// default limit, capped at its maximum like ?limit=. It is mutually exclusive
+// with ?cursor=. On invalid input it writes a 400 and returns false.
+func (h *Handler) page(c *gin.Context, endpoint string) (pageParams, bool) {
+	rawPage, rawSize := c.Query("page"), c.Query("page_size")
+	if rawPage == "" && rawSize == "" {
+		return pageParams{}, true
+	}
+	if c.Query("cursor") != "" {
+		errorResponse(c, http.StatusBadRequest, CodeValidation, "page cannot be combined with cursor")
+		return pageParams{}, false
+	}
+	cfg := h.limits[endpoint]
+	p := pageParams{Page: 1, Size: cfg.Default}
+	if rawPage != "" {
+		n, err := strconv.Atoi(rawPage)
+		if err != nil || n < 1 {
+			errorResponse(c, http.StatusBadRequest, CodeValidation, "page must be an integer >= 1")
+			return pageParams{}, false
+		}
+		p.Page = n
+	}
+	if rawSize != "" {
+		n, err := strconv.Atoi(rawSize)
+		if err != nil || n < 1 {
+			errorResponse(c, http.StatusBadRequest, CodeValidation, "page_size must be an integer >= 1")
+			return pageParams{}, false
+		}
+		p.Size = clampLimit(n, cfg.Max, cfg.Default)
+		p.Clamped = n > cfg.Max
+	}
+	if p.Page-1 > maxPageOffset/p.Size {
+		errorResponse(c, http.StatusBadRequest, CodeValidation, "page is too deep; use cursor pagination")
+		return pageParams{}, false
+	}
+	return p, true
+}
+
+// addPageMeta adds page, page_size and total_pages to meta when page-number
+// paging was requested.
+func addPageMeta(meta gin.H, p pageParams, total int) {
+	if p.Page == 0 {
+		return
+	}
+	meta["page"] = p.Page
+	meta["page_size"] = p.Size
+	meta["total_pages"] = p.totalPages(total)
+}
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/nitesh/news_service/internal/service"
+	"github.com/nitesh/news_service/internal/store"
+	"github.com/nitesh/news_service/pkg/models"
+)
+
+func TestDefaultLimitsWithinStoreCap(t *testing.T) {
+	for name, cfg := range DefaultLimits {
+		if cfg.Max > store.MaxQueryRows || cfg.Default > cfg.Max {
+			t.Errorf("%s: %+v, want Default <= Max <= %d", name, cfg, store.MaxQueryRows)
+		}
+	}
+}
+
+func TestSetMaxLimit(t *testing.T) {
+	tests := []struct {
+		name     string
+		endpoint string
+		max      int
+		want     LimitConfig
+	}{
+		{"lowers max and default", "search", 5, LimitConfig{Default: 5, Max: 5}},
+		{"raises max", "search", 500, LimitConfig{Default: 10, Max: 500}},
+		{"capped at the store ceiling", "unsummarized", 5000, LimitConfig{Default: 50, Max: store.MaxQueryRows}},
+		{"non-positive is ignored", "search", 0, DefaultLimits["search"]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHandler(nil)
+			h.SetMaxLimit(tt.endpoint, tt.max)
+			if got := h.limits[tt.endpoint]; got != tt.want {
+				t.Errorf("limits[%s] = %+v, want %+v", tt.endpoint, got, tt.want)
+			}
+		})
+	}
+
+	h := NewHandler(nil)
+	h.SetMaxLimit("nope", 10)
+	if _, ok := h.limits["nope"]; ok {
+		t.Error("SetMaxLimit added an unknown endpoint")
+	}
+}
+
+// backfillStore serves n articles without summaries or coordinates, at most
+// store.MaxQueryRows per query like the real store.
+type backfillStore struct {
+	service.ArticleStore
+	n int
+}
+
+func (b *backfillStore) page(limit int) []*models.Article {
+	limit = min(limit, b.n, store.MaxQueryRows)
+	out := make([]*models.Article, limit)
+	for i := range out {
+		out[i] = &models.Article{ID: fmt.Sprintf("%08d-0000-0000-0000-000000000000", i)}
+	}
+	return out
+}
+
+func (b *backfillStore) ArticlesWithoutSummary(ctx context.Context, after string, limit int) ([]*models.Article, error) {
+	return b.page(limit), nil
+}
+
+func (b *backfillStore) WithoutGeo(ctx context.Context, after string, limit int) ([]*models.Article, error) {
+	return b.page(limit), nil
+}
+
+func TestBackfillNextAfter(t *testing.T) {
+	tests := []struct {
+		name          string
+		available     int
+		max           int
+		query         string
+		wantCount     int
+		wantNextAfter bool
+	}{
+		{"full page", 100, 0, "?limit=50", 50, true},
+		{"last page", 30, 0, "?limit=50", 30, false},
+		{"max raised past the store cap", 2500, 5000, "?limit=5000", store.MaxQueryRows, true},
+	}
+	for _, endpoint := range []string{"unsummarized", "ungeocoded"} {
+		for _, tt := range tests {
+			t.Run(endpoint+"/"+tt.name, func(t *testing.T) {
+				h := NewHandler(service.NewService(&backfillStore{n: tt.available}, nil, nil))
+				if tt.max > 0 {
+					h.SetMaxLimit(endpoint, tt.max)
+				}
+				handler := h.Unsummarized
+				if endpoint == "ungeocoded" {
+					handler = h.Ungeocoded
+				}
+				w := serve(handler, http.MethodGet, "/v1/news/"+endpoint+tt.query, "")
+				if w.Code != http.StatusOK {
+					t.Fatalf("status = %d, want 200 (body %s)", w.Code, w.Body)
+				}
+				var resp struct {
+					Meta struct {
+						Count     int    `json:"count"`
+						NextAfter string `json:"next_after"`
+					} `json:"meta"`
+				}
+				if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+					t.Fatalf("decode: %v", err)
+				}
+				if resp.Meta.Count != tt.wantCount {
+					t.Errorf("count = %d, want %d", resp.Meta.Count, tt.wantCount)
+				}
+				if (resp.Meta.NextAfter != "") != tt.wantNextAfter {
+					t.Errorf("next_after = %q, want present = %v", resp.Meta.NextAfter, tt.wantNextAfter)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HeaderAPIKey is the request header carrying the API key.
+const HeaderAPIKey = "X-API-Key"
+
+// APIKey rejects requests whose X-API-Key header isn't one of keys with 401.
+// Blank keys are ignored; with no keys configured the middleware lets every
+// request through, so local development works without credentials.
+func APIKey(keys []string) gin.HandlerFunc {
+	valid := [][]byte{}
+	for _, k := range keys {
+		if k = strings.TrimSpace(k); k != "" {
+			valid = append(valid, []byte(k))
+		}
+	}
+
+	return func(c *gin.Context) {
+		if len(valid) == 0 {
+			c.Next()
+			return
+		}
+		got := []byte(c.GetHeader(HeaderAPIKey))
+		for _, k := range valid {
+			if subtle.ConstantTimeCompare(got, k) == 1 {
+				c.Next()
+				return
+			}
+		}
+		c.Header("WWW-Authenticate", HeaderAPIKey)
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing or invalid API key", "code": "unauthorized"})
+	}
+}
@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ParseCIDRs parses a comma-separated list of CIDR ranges; bare addresses are
+// taken as single hosts. Blank entries are ignored.
+func ParseCIDRs(list string) ([]netip.Prefix, error) {
+	out := []netip.Prefix{}
+	for _, s := range strings.Split(list, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		if !strings.Contains(s, "/") {
+			addr, err := netip.ParseAddr(s)
+			if err != nil {
+				return nil, fmt.Errorf("invalid address %q: %w", s, err)
+			}
+			addr = addr.Unmap()
+			out = append(out, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		p, err := netip.ParsePrefix(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q: %w", s, err)
+		}
+		if p.Addr().Is4In6() {
+			p = netip.PrefixFrom(p.Addr().Unmap(), p.Bits()-96)
+		}
+		out = append(out, p.Masked())
+	}
+	return out, nil
+}
+
+// IPAllowList rejects requests whose client address isn't in allowed with 403.
+// The client address is the connection's peer, or, when that peer is one of
+// trustedProxies, the right-most X-Forwarded-For entry not itself a trusted
+// proxy; untrusted peers can't spoof it. With no ranges allowed the middleware
+// lets every request through, so local development works without configuration.
+func IPAllowList(allowed, trustedProxies []netip.Prefix) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if len(allowed) == 0 {
+			c.Next()
+			return
+		}
+		addr, ok := clientAddr(c.Request, trustedProxies)
+		if !ok || !containsAddr(allowed, addr) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "client address not allowed", "code": "forbidden"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// clientAddr resolves the client address of r as described on IPAllowList.
+func clientAddr(r *http.Request, trustedProxies []netip.Prefix) (netip.Addr, bool) {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	addr = addr.Unmap()
+	if !containsAddr(trustedProxies, addr) {
+		return addr, true
+	}
+	forwarded := r.Header.Values("X-Forwarded-For")
+	if len(forwarded) == 0 {
+		// the proxy is talking to us itself (e.g. a health check)
+		return addr, true
+	}
+	hops := strings.Split(strings.Join(forwarded, ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			// a malformed entry means the chain can't be trusted past this point
+			return netip.Addr{}, false
+		}
+		addr = hop.Unmap()
+		if !containsAddr(trustedProxies, addr) {
+			return addr, true
+		}
+	}
+	// every hop was a trusted proxy; the left-most one is as close to the client as it gets
+	return addr, true
+}
+
+func containsAddr(prefixes []netip.Prefix, addr netip.Addr) bool {
+	for _, p := range prefixes {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
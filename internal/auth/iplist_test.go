@@ -0,0 +1,119 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func TestParseCIDRs(t *testing.T) {
+	tests := []struct {
+		list    string
+		want    string
+		wantErr bool
+	}{
+		{"", "[]", false},
+		{"10.0.0.0/8, 192.168.1.7", "[10.0.0.0/8 192.168.1.7/32]", false},
+		{"10.1.2.3/8", "[10.0.0.0/8]", false},
+		{"::1, fd00::/8", "[::1/128 fd00::/8]", false},
+		{"::ffff:10.0.0.0/104", "[10.0.0.0/8]", false},
+		{" , 10.0.0.1 ,", "[10.0.0.1/32]", false},
+		{"10.0.0.0/33", "", true},
+		{"example.com", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParseCIDRs(tt.list)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseCIDRs(%q) error = %v, want error %v", tt.list, err, tt.wantErr)
+			continue
+		}
+		if err == nil && fmt.Sprint(got) != tt.want {
+			t.Errorf("ParseCIDRs(%q) = %s, want %s", tt.list, got, tt.want)
+		}
+	}
+}
+
+func TestClientAddr(t *testing.T) {
+	trusted, _ := ParseCIDRs("10.0.0.0/8")
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  []string // X-Forwarded-For header values
+		want       string   // "" means no usable address
+	}{
+		{"direct client", "198.51.100.1:4000", nil, "198.51.100.1"},
+		{"untrusted peer can't spoof", "198.51.100.1:4000", []string{"10.0.0.9"}, "198.51.100.1"},
+		{"trusted proxy", "10.0.0.1:4000", []string{"198.51.100.1"}, "198.51.100.1"},
+		{"spoofed entry left of the client", "10.0.0.1:4000", []string{"10.0.0.9, 198.51.100.1"}, "198.51.100.1"},
+		{"chain of trusted proxies", "10.0.0.1:4000", []string{"203.0.113.5, 198.51.100.1, 10.0.0.2"}, "198.51.100.1"},
+		{"repeated headers", "10.0.0.1:4000", []string{"203.0.113.5", "198.51.100.1"}, "198.51.100.1"},
+		{"every hop trusted", "10.0.0.1:4000", []string{"10.0.0.3, 10.0.0.2"}, "10.0.0.3"},
+		{"trusted proxy without the header", "10.0.0.1:4000", nil, "10.0.0.1"},
+		{"malformed hop", "10.0.0.1:4000", []string{"198.51.100.1, not-an-ip"}, ""},
+		{"malformed hop past the client", "10.0.0.1:4000", []string{"not-an-ip, 198.51.100.1"}, "198.51.100.1"},
+		{"ipv4-mapped peer", "[::ffff:10.0.0.1]:4000", []string{"198.51.100.1"}, "198.51.100.1"},
+		{"ipv6 client", "10.0.0.1:4000", []string{"2001:db8::1"}, "2001:db8::1"},
+		{"unparsable peer", "somewhere", nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/v1/admin/stats", nil)
+			r.RemoteAddr = tt.remoteAddr
+			for _, v := range tt.forwarded {
+				r.Header.Add("X-Forwarded-For", v)
+			}
+			addr, ok := clientAddr(r, trusted)
+			got := ""
+			if ok {
+				got = addr.String()
+			}
+			if got != tt.want {
+				t.Errorf("clientAddr = %q (ok %v), want %q", got, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestIPAllowList(t *testing.T) {
+	allowed, _ := ParseCIDRs("192.168.0.0/16")
+	trusted, _ := ParseCIDRs("10.0.0.1")
+	tests := []struct {
+		name       string
+		allowed    []netip.Prefix
+		remoteAddr string
+		forwarded  string
+		want       int
+	}{
+		{"allowed client", allowed, "192.168.1.5:4000", "", http.StatusOK},
+		{"other client", allowed, "198.51.100.1:4000", "", http.StatusForbidden},
+		{"spoofed header from an untrusted peer", allowed, "198.51.100.1:4000", "192.168.1.5", http.StatusForbidden},
+		{"allowed client behind the proxy", allowed, "10.0.0.1:4000", "192.168.1.5", http.StatusOK},
+		{"other client behind the proxy", allowed, "10.0.0.1:4000", "192.168.1.5, 198.51.100.1", http.StatusForbidden},
+		{"no ranges allows all", nil, "198.51.100.1:4000", "", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(IPAllowList(tt.allowed, trusted))
+			r.GET("/v1/admin/stats", func(c *gin.Context) { c.Status(http.StatusOK) })
+			req := httptest.NewRequest(http.MethodGet, "/v1/admin/stats", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.forwarded != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
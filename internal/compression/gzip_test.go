@@ -0,0 +1,157 @@
+package compression
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func TestAcceptsGzip(t *testing.T) {
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{"gzip", true},
+		{"deflate, gzip;q=0.8", true},
+		{"GZIP", true},
+		{"*", true},
+		{"", false},
+		{"deflate, br", false},
+		{"gzip;q=0", false},
+		{"gzip; q=0.000", false},
+		{"gzip;q=0, *", true},
+	}
+	for _, tt := range tests {
+		if got := acceptsGzip(tt.header); got != tt.want {
+			t.Errorf("acceptsGzip(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
+
+// minSize is the threshold the tests configure.
+const minSize = 100
+
+func TestGzip(t *testing.T) {
+	large := strings.Repeat("a", minSize)
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		encoding string
+		handler  gin.HandlerFunc
+		wantGzip bool
+	}{
+		{"at the threshold", http.MethodGet, "/v1/news", "gzip", text(large), true},
+		{"written in pieces", http.MethodGet, "/v1/news", "gzip", func(c *gin.Context) {
+			for i := 0; i < 10; i++ {
+				c.Writer.WriteString(large[:minSize/10])
+			}
+		}, true},
+		{"under the threshold", http.MethodGet, "/v1/news", "gzip", text(large[1:]), false},
+		{"client doesn't accept gzip", http.MethodGet, "/v1/news", "", text(large), false},
+		{"excluded path", http.MethodGet, "/metrics", "gzip", text(large), false},
+		{"head request", http.MethodHead, "/v1/news", "gzip", text(large), false},
+		{"already encoded", http.MethodGet, "/v1/news", "gzip", func(c *gin.Context) {
+			c.Header("Content-Encoding", "br")
+			c.String(http.StatusOK, large)
+		}, false},
+		{"event stream", http.MethodGet, "/v1/news", "gzip", func(c *gin.Context) {
+			c.Header("Content-Type", "text/event-stream")
+			c.String(http.StatusOK, large)
+		}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(Gzip(minSize, "/metrics"))
+			r.Handle(tt.method, "/v1/news", tt.handler)
+			r.Handle(tt.method, "/metrics", tt.handler)
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.encoding != "" {
+				req.Header.Set("Accept-Encoding", tt.encoding)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			gzipped := w.Header().Get("Content-Encoding") == "gzip"
+			if gzipped != tt.wantGzip {
+				t.Fatalf("Content-Encoding = %q, want gzip = %v", w.Header().Get("Content-Encoding"), tt.wantGzip)
+			}
+			if tt.path != "/metrics" && tt.method != http.MethodHead && w.Header().Get("Vary") != "Accept-Encoding" {
+				t.Errorf("Vary = %q, want Accept-Encoding", w.Header().Get("Vary"))
+			}
+			if tt.method == http.MethodHead {
+				return
+			}
+			body := w.Body.Bytes()
+			if gzipped {
+				body = gunzip(t, body)
+			}
+			if len(body) < minSize-1 || strings.Trim(string(body), "a") != "" {
+				t.Errorf("body = %q, want the handler's output", body)
+			}
+		})
+	}
+}
+
+// TestGzipFlush checks a flushing handler gets its output to the client
+// compressed before it returns, even under the threshold.
+func TestGzipFlush(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := gin.New()
+	r.Use(Gzip(minSize))
+	r.GET("/stream", func(c *gin.Context) {
+		c.Writer.WriteString("first")
+		c.Writer.Flush()
+		if !w.Flushed {
+			t.Error("Flush did not reach the client")
+		}
+		if w.Header().Get("Content-Encoding") != "gzip" {
+			t.Errorf("Content-Encoding = %q after Flush, want gzip", w.Header().Get("Content-Encoding"))
+		}
+		zr, err := gzip.NewReader(bytes.NewReader(w.Body.Bytes()))
+		if err != nil {
+			t.Fatalf("gzip header not flushed: %v", err)
+		}
+		got := make([]byte, len("first"))
+		if _, err := io.ReadFull(zr, got); err != nil || string(got) != "first" {
+			t.Errorf("flushed data = %q, %v, want %q", got, err, "first")
+		}
+		c.Writer.WriteString(" second")
+	})
+	req := httptest.NewRequest(http.MethodGet, "/stream", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	r.ServeHTTP(w, req)
+
+	if got := string(gunzip(t, w.Body.Bytes())); got != "first second" {
+		t.Errorf("body = %q, want %q", got, "first second")
+	}
+}
+
+func text(s string) gin.HandlerFunc {
+	return func(c *gin.Context) { c.String(http.StatusOK, s) }
+}
+
+func gunzip(t *testing.T, b []byte) []byte {
+	t.Helper()
+	zr, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		t.Fatalf("gzip.NewReader: %v", err)
+	}
+	out, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("gunzip: %v", err)
+	}
+	return out
+}
@@ -0,0 +1,62 @@
+package cors
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// allowedMethods are the methods used by the v1 API.
+const allowedMethods = "GET, POST, PUT, DELETE, OPTIONS"
+
+// preflightMaxAge is how long (seconds) browsers may cache a preflight response.
+const preflightMaxAge = "600"
+
+// Middleware adds CORS headers for requests whose Origin is in allowed ("*"
+// allows any origin) and answers preflight OPTIONS requests with 204.
+// Origins not on the list get no Access-Control-Allow-Origin header, so the
+// browser blocks them; the request itself is still served normally.
+func Middleware(allowed []string) gin.HandlerFunc {
+	allowAll := false
+	set := make(map[string]bool, len(allowed))
+	for _, o := range allowed {
+		o = strings.TrimRight(strings.TrimSpace(o), "/")
+		if o == "*" {
+			allowAll = true
+		}
+		if o != "" {
+			set[o] = true
+		}
+	}
+
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			c.Next()
+			return
+		}
+		c.Writer.Header().Add("Vary", "Origin")
+		if !allowAll && !set[origin] {
+			c.Next()
+			return
+		}
+
+		h := c.Writer.Header()
+		h.Set("Access-Control-Allow-Origin", origin)
+		h.Set("Access-Control-Expose-Headers", "Retry-After")
+
+		if c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != "" {
+			h.Add("Vary", "Access-Control-Request-Method")
+			h.Add("Vary", "Access-Control-Request-Headers")
+			h.Set("Access-Control-Allow-Methods", allowedMethods)
+			if reqHeaders := c.GetHeader("Access-Control-Request-Headers"); reqHeaders != "" {
+				h.Set("Access-Control-Allow-Headers", reqHeaders)
+			}
+			h.Set("Access-Control-Max-Age", preflightMaxAge)
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+		c.Next()
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"fmt"
+	"strconv"
+	"strings"
 )
 
 // StringSlice is a thin wrapper around []string that implements
@@ -51,4 +53,22 @@ func (s StringSlice) Value() (driver.Value, error) {
 		return nil, err
 	}
 	return string(b), nil
-}
\ No newline at end of file
+}
+
+// Vector is a float32 slice that implements driver.Valuer using pgvector's
+// text format ("[1,2,3]"), so it can be bound to a vector column with $n::vector.
+type Vector []float32
+
+// Value implements driver.Valuer
+func (v Vector) Value() (driver.Value, error) {
+	var b strings.Builder
+	b.WriteByte('[')
+	for i, f := range v {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(strconv.FormatFloat(float64(f), 'f', -1, 32))
+	}
+	b.WriteByte(']')
+	return b.String(), nil
+}
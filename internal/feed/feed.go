@@ -0,0 +1,285 @@
+package feed
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	dbtypes "github.com/nitesh/news_service/internal/db"
+	"github.com/nitesh/news_service/pkg/models"
+)
+
+// maxFeedBytes caps how much of a feed body is read; larger feeds are rejected.
+const maxFeedBytes = 10 << 20
+
+// ErrNotFeed is returned when a URL serves something other than an RSS or Atom
+// document, e.g. an HTML error or login page.
+var ErrNotFeed = errors.New("not a feed")
+
+// ErrTooLarge is returned when a feed body exceeds maxFeedBytes.
+var ErrTooLarge = errors.New("feed too large")
+
+// ErrForbiddenURL is returned for feed URLs that aren't http(s), or that connect
+// to a loopback, private, link-local or other non-public address.
+var ErrForbiddenURL = errors.New("feed url not allowed")
+
+// NewClient returns the http.Client to pass to Fetch for user-supplied URLs.
+// It only connects to public addresses: the check runs on the resolved IP at
+// dial time, so it also covers redirects and host names that resolve into the
+// internal network. Proxies are not used, as they would dial on our behalf.
+func NewClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   10 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   dialPublicOnly,
+	}
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	tr.Proxy = nil
+	tr.DialContext = dialer.DialContext
+	return &http.Client{Transport: tr}
+}
+
+// dialPublicOnly is a net.Dialer Control func refusing non-public destinations.
+func dialPublicOnly(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !publicIP(ip) {
+		return fmt.Errorf("%w: address %s is not public", ErrForbiddenURL, host)
+	}
+	return nil
+}
+
+// publicIP reports whether ip is a globally routable unicast address.
+func publicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast())
+}
+
+// Feed is a parsed RSS 2.0 or Atom document.
+type Feed struct {
+	Title    string
+	Articles []*models.Article
+}
+
+// Fetch downloads feedURL with hc and parses it as RSS 2.0 or Atom.
+// Each article's Source is set to the feed title. ctx bounds the whole
+// download. URLs that aren't http(s) fail with ErrForbiddenURL; use a client
+// from NewClient to keep user-supplied URLs off internal addresses as well.
+// Responses whose Content-Type isn't XML (see feedContentType) fail
+// with ErrNotFeed before the body is read, and bodies over maxFeedBytes with
+// ErrTooLarge.
+func Fetch(ctx context.Context, hc *http.Client, feedURL string) (*Feed, error) {
+	u, err := url.Parse(feedURL)
+	if err != nil {
+		return nil, fmt.Errorf("feed url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("%w: scheme %q", ErrForbiddenURL, u.Scheme)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, feedURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("feed new request: %w", err)
+	}
+	req.Header.Set("Accept", "application/rss+xml, application/atom+xml, application/xml, text/xml")
+
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("feed fetch: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("feed fetch: status=%d", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); !feedContentType(ct) {
+		return nil, fmt.Errorf("%w: content type %q", ErrNotFeed, ct)
+	}
+
+	// read one byte past the cap to tell a full-size feed from a truncated one
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxFeedBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("feed read: %w", err)
+	}
+	if len(body) > maxFeedBytes {
+		return nil, fmt.Errorf("%w: over %d bytes", ErrTooLarge, maxFeedBytes)
+	}
+	return Parse(body)
+}
+
+// feedContentType reports whether a response Content-Type may carry a feed:
+// any XML type (application/rss+xml, application/atom+xml, text/xml, ...),
+// plus a missing, text/plain or application/octet-stream one, which some
+// servers send for static feed files. Parse still checks the document itself.
+func feedContentType(ct string) bool {
+	if ct == "" {
+		return true
+	}
+	mt, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return false
+	}
+	return strings.HasSuffix(mt, "/xml") || strings.HasSuffix(mt, "+xml") ||
+		mt == "text/plain" || mt == "application/octet-stream"
+}
+
+// looksLikeHTML reports whether data starts (after whitespace and a BOM) like an HTML page.
+func looksLikeHTML(data []byte) bool {
+	head := bytes.TrimLeft(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")), " \t\r\n")
+	if len(head) > 64 {
+		head = head[:64]
+	}
+	head = bytes.ToLower(head)
+	return bytes.HasPrefix(head, []byte("<!doctype html")) || bytes.HasPrefix(head, []byte("<html"))
+}
+
+// Parse detects the feed flavour from the root element and maps its items to articles.
+// HTML pages and other XML documents fail with ErrNotFeed.
+func Parse(data []byte) (*Feed, error) {
+	if looksLikeHTML(data) {
+		return nil, fmt.Errorf("%w: got an HTML page", ErrNotFeed)
+	}
+	var root struct {
+		XMLName xml.Name
+	}
+	if err := xml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("feed parse: %w", err)
+	}
+	switch strings.ToLower(root.XMLName.Local) {
+	case "rss":
+		return parseRSS(data)
+	case "feed":
+		return parseAtom(data)
+	default:
+		return nil, fmt.Errorf("%w: unsupported root element <%s>", ErrNotFeed, root.XMLName.Local)
+	}
+}
+
+type rssDoc struct {
+	Channel struct {
+		Title string `xml:"title"`
+		Items []struct {
+			Title       string   `xml:"title"`
+			Description string   `xml:"description"`
+			Link        string   `xml:"link"`
+			PubDate     string   `xml:"pubDate"`
+			Categories  []string `xml:"category"`
+			// full body from the RSS content module (<content:encoded>)
+			Encoded string `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+		} `xml:"item"`
+	} `xml:"channel"`
+}
+
+func parseRSS(data []byte) (*Feed, error) {
+	var doc rssDoc
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("rss parse: %w", err)
+	}
+	f := &Feed{Title: strings.TrimSpace(doc.Channel.Title)}
+	for _, it := range doc.Channel.Items {
+		f.Articles = append(f.Articles, &models.Article{
+			Title:       strings.TrimSpace(it.Title),
+			Description: strings.TrimSpace(it.Description),
+			Content:     strings.TrimSpace(it.Encoded),
+			URL:         strings.TrimSpace(it.Link),
+			PublishedAt: parseDate(it.PubDate),
+			Source:      f.Title,
+			Categories:  trimAll(it.Categories),
+		})
+	}
+	return f, nil
+}
+
+type atomDoc struct {
+	Title   string `xml:"title"`
+	Entries []struct {
+		Title     string `xml:"title"`
+		Summary   string `xml:"summary"`
+		Content   string `xml:"content"`
+		Published string `xml:"published"`
+		Updated   string `xml:"updated"`
+		Links     []struct {
+			Href string `xml:"href,attr"`
+			Rel  string `xml:"rel,attr"`
+		} `xml:"link"`
+		Categories []struct {
+			Term string `xml:"term,attr"`
+		} `xml:"category"`
+	} `xml:"entry"`
+}
+
+func parseAtom(data []byte) (*Feed, error) {
+	var doc atomDoc
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("atom parse: %w", err)
+	}
+	f := &Feed{Title: strings.TrimSpace(doc.Title)}
+	for _, e := range doc.Entries {
+		// prefer rel="alternate" (or no rel), which is the article page
+		link := ""
+		for _, l := range e.Links {
+			if l.Rel == "" || l.Rel == "alternate" {
+				link = l.Href
+				break
+			}
+		}
+		if link == "" && len(e.Links) > 0 {
+			link = e.Links[0].Href
+		}
+		desc := e.Summary
+		if desc == "" {
+			desc = e.Content
+		}
+		date := e.Published
+		if date == "" {
+			date = e.Updated
+		}
+		cats := make([]string, 0, len(e.Categories))
+		for _, c := range e.Categories {
+			cats = append(cats, c.Term)
+		}
+		f.Articles = append(f.Articles, &models.Article{
+			Title:       strings.TrimSpace(e.Title),
+			Description: strings.TrimSpace(desc),
+			Content:     strings.TrimSpace(e.Content),
+			URL:         strings.TrimSpace(link),
+			PublishedAt: parseDate(date),
+			Source:      f.Title,
+			Categories:  trimAll(cats),
+		})
+	}
+	return f, nil
+}
+
+// parseDate returns the parsed date in UTC, or the zero time if it can't be parsed
+// (Ingest then defaults it to now). RSS (RFC 822/1123) and Atom (RFC 3339) dates
+// are covered by models.ParsePublishedAt.
+func parseDate(s string) time.Time {
+	t, err := models.ParsePublishedAt(s)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// trimAll trims items and drops empty ones.
+func trimAll(in []string) dbtypes.StringSlice {
+	out := dbtypes.StringSlice{}
+	for _, s := range in {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
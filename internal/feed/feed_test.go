@@ -0,0 +1,134 @@
+package feed
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const testRSS = `<?xml version="1.0"?>
+<rss version="2.0"><channel><title>Example</title>
+<item><title>Hello</title><link>https://example.com/hello</link></item>
+</channel></rss>`
+
+func TestPublicIP(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"fd00::1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"0.0.0.0", false},
+		{"::", false},
+		{"224.0.0.1", false},
+		{"::ffff:127.0.0.1", false},
+	}
+	for _, tt := range tests {
+		if got := publicIP(net.ParseIP(tt.ip)); got != tt.want {
+			t.Errorf("publicIP(%s) = %v, want %v", tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestFetchRejectsScheme(t *testing.T) {
+	for _, u := range []string{"file:///etc/passwd", "ftp://example.com/feed.xml", "gopher://example.com"} {
+		if _, err := Fetch(context.Background(), NewClient(), u); !errors.Is(err, ErrForbiddenURL) {
+			t.Errorf("Fetch(%s) error = %v, want ErrForbiddenURL", u, err)
+		}
+	}
+}
+
+func TestFetchRejectsLoopback(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(testRSS))
+	}))
+	defer srv.Close()
+
+	if _, err := Fetch(context.Background(), NewClient(), srv.URL); !errors.Is(err, ErrForbiddenURL) {
+		t.Fatalf("Fetch(%s) error = %v, want ErrForbiddenURL", srv.URL, err)
+	}
+}
+
+// TestFetchRejectsRedirect checks the dial-time check also applies to redirect
+// targets: the public-looking first hop is served by a client allowed to reach
+// it, but the redirect to loopback must still be refused.
+func TestFetchRejectsRedirect(t *testing.T) {
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(testRSS))
+	}))
+	defer internal.Close()
+
+	hc := NewClient()
+	hc.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if req.URL.Host != internal.Listener.Addr().String() {
+			t.Errorf("unexpected redirect to %s", req.URL)
+		}
+		return nil
+	}
+	redirector := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, internal.URL, http.StatusFound)
+	})
+	// serve the first hop in-process so only the redirect goes through the dialer
+	hc.Transport = &firstHop{first: redirector, next: hc.Transport}
+
+	if _, err := Fetch(context.Background(), hc, "http://feeds.example.com/rss"); !errors.Is(err, ErrForbiddenURL) {
+		t.Fatalf("Fetch error = %v, want ErrForbiddenURL", err)
+	}
+}
+
+// firstHop answers requests to feeds.example.com with first and sends the rest to next.
+type firstHop struct {
+	first http.Handler
+	next  http.RoundTripper
+}
+
+func (f *firstHop) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Host != "feeds.example.com" {
+		return f.next.RoundTrip(req)
+	}
+	w := httptest.NewRecorder()
+	f.first.ServeHTTP(w, req)
+	return w.Result(), nil
+}
+
+func TestFetch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rss":
+			w.Header().Set("Content-Type", "application/rss+xml")
+			w.Write([]byte(testRSS))
+		case "/html":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte("<html></html>"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	// the test server is on loopback, so use its own client rather than NewClient
+	f, err := Fetch(context.Background(), srv.Client(), srv.URL+"/rss")
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if f.Title != "Example" || len(f.Articles) != 1 || f.Articles[0].URL != "https://example.com/hello" {
+		t.Errorf("Fetch = %+v", f)
+	}
+	if _, err := Fetch(context.Background(), srv.Client(), srv.URL+"/html"); !errors.Is(err, ErrNotFeed) {
+		t.Errorf("Fetch(html) error = %v, want ErrNotFeed", err)
+	}
+	if _, err := Fetch(context.Background(), srv.Client(), srv.URL+"/missing"); err == nil {
+		t.Error("Fetch(404) succeeded")
+	}
+}
@@ -0,0 +1,52 @@
+package geo
+
+import "math"
+
+// EarthRadiusKm is the mean Earth radius used for great-circle distances.
+// It matches the constant in the SQL Haversine query (store.Nearby).
+const EarthRadiusKm = 6371.0
+
+// KmPerMile converts statute miles to kilometers.
+const KmPerMile = 1.609344
+
+// DistanceKm returns the great-circle distance in kilometers between two
+// lat/lon points (degrees), using the Haversine formula.
+func DistanceKm(lat1, lon1, lat2, lon2 float64) float64 {
+	dLat := toRad(lat2 - lat1)
+	dLon := toRad(lon2 - lon1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	c := 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+	return EarthRadiusKm * c
+}
+
+// BoundingBox returns a lat/lon box that contains every point within radiusKm
+// of (lat, lon). minLon > maxLon means the box crosses the antimeridian; near
+// the poles (or for very large radii) the box spans all longitudes.
+func BoundingBox(lat, lon, radiusKm float64) (minLat, minLon, maxLat, maxLon float64) {
+	dLat := radiusKm / EarthRadiusKm * 180 / math.Pi
+	minLat = math.Max(lat-dLat, -90)
+	maxLat = math.Min(lat+dLat, 90)
+	if minLat == -90 || maxLat == 90 {
+		return minLat, -180, maxLat, 180
+	}
+	// widest longitude span of the circle, reached at latitude asin(sin(lat)/cos(r/R))
+	x := math.Sin(radiusKm/EarthRadiusKm) / math.Cos(toRad(lat))
+	if x >= 1 {
+		return minLat, -180, maxLat, 180
+	}
+	dLon := math.Asin(x) * 180 / math.Pi
+	return minLat, wrapLon(lon - dLon), maxLat, wrapLon(lon + dLon)
+}
+
+func toRad(d float64) float64 { return d * math.Pi / 180 }
+
+// wrapLon normalizes a longitude into [-180, 180].
+func wrapLon(lon float64) float64 {
+	if lon > 180 {
+		return lon - 360
+	}
+	if lon < -180 {
+		return lon + 360
+	}
+	return lon
+}
@@ -0,0 +1,79 @@
+package geo
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDistanceKm(t *testing.T) {
+	tests := []struct {
+		name                   string
+		lat1, lon1, lat2, lon2 float64
+		want, tolerance        float64
+	}{
+		{"same point", 12.97, 77.59, 12.97, 77.59, 0, 1e-9},
+		{"london to paris", 51.5074, -0.1278, 48.8566, 2.3522, 343.5, 1},
+		{"bangalore to chennai", 12.9716, 77.5946, 13.0827, 80.2707, 290.2, 1},
+		{"across the antimeridian", 0, 179.5, 0, -179.5, 111.2, 0.5},
+		{"pole to pole", 90, 0, -90, 0, math.Pi * EarthRadiusKm, 1e-6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DistanceKm(tt.lat1, tt.lon1, tt.lat2, tt.lon2)
+			if math.Abs(got-tt.want) > tt.tolerance {
+				t.Errorf("DistanceKm = %.3f, want %.3f ± %v", got, tt.want, tt.tolerance)
+			}
+			if back := DistanceKm(tt.lat2, tt.lon2, tt.lat1, tt.lon1); math.Abs(back-got) > 1e-9 {
+				t.Errorf("DistanceKm is not symmetric: %v vs %v", got, back)
+			}
+		})
+	}
+}
+
+func TestBoundingBox(t *testing.T) {
+	tests := []struct {
+		name          string
+		lat, lon, r   float64
+		wantFullWorld bool
+		wantWrap      bool
+	}{
+		{"mid latitude", 12.97, 77.59, 10, false, false},
+		{"near the antimeridian", 0, 179.9, 50, false, true},
+		{"touching the pole", 89.95, 0, 10, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			minLat, minLon, maxLat, maxLon := BoundingBox(tt.lat, tt.lon, tt.r)
+			if full := minLon == -180 && maxLon == 180; full != tt.wantFullWorld {
+				t.Fatalf("full longitude span = %v, want %v (box %v,%v,%v,%v)", full, tt.wantFullWorld, minLat, minLon, maxLat, maxLon)
+			}
+			if wrap := minLon > maxLon; wrap != tt.wantWrap {
+				t.Fatalf("crosses antimeridian = %v, want %v", wrap, tt.wantWrap)
+			}
+			// every point on the circle must fall inside the box
+			for bearing := 0.0; bearing < 360; bearing += 5 {
+				plat, plon := destination(tt.lat, tt.lon, bearing, tt.r)
+				if plat < minLat-1e-9 || plat > maxLat+1e-9 || !inLonRange(plon, minLon, maxLon) {
+					t.Errorf("point %.4f,%.4f (bearing %v) outside box %v,%v,%v,%v", plat, plon, bearing, minLat, minLon, maxLat, maxLon)
+				}
+			}
+		})
+	}
+}
+
+// destination returns the point distKm from (lat, lon) along bearing (degrees).
+func destination(lat, lon, bearing, distKm float64) (float64, float64) {
+	d := distKm / EarthRadiusKm
+	lat1, lon1, b := toRad(lat), toRad(lon), toRad(bearing)
+	lat2 := math.Asin(math.Sin(lat1)*math.Cos(d) + math.Cos(lat1)*math.Sin(d)*math.Cos(b))
+	lon2 := lon1 + math.Atan2(math.Sin(b)*math.Sin(d)*math.Cos(lat1), math.Cos(d)-math.Sin(lat1)*math.Sin(lat2))
+	return lat2 * 180 / math.Pi, wrapLon(lon2 * 180 / math.Pi)
+}
+
+func inLonRange(lon, minLon, maxLon float64) bool {
+	const eps = 1e-9
+	if minLon > maxLon {
+		return lon >= minLon-eps || lon <= maxLon+eps
+	}
+	return lon >= minLon-eps && lon <= maxLon+eps
+}
@@ -0,0 +1,144 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// Header is the request header carrying the client's idempotency key.
+const Header = "Idempotency-Key"
+
+// maxKeyLen bounds the accepted key length.
+const maxKeyLen = 255
+
+// inFlight marks a key whose first request is still being processed.
+const inFlight = "in-flight"
+
+// inFlightTTL bounds how long a crashed request can block its key.
+const inFlightTTL = 5 * time.Minute
+
+// storedResponse is what a repeated request gets back.
+type storedResponse struct {
+	Status      int    `json:"status"`
+	ContentType string `json:"content_type"`
+	Body        []byte `json:"body"`
+}
+
+// Store keeps responses of requests sent with an Idempotency-Key in Redis.
+type Store struct {
+	rdb *redis.Client
+	ttl time.Duration
+}
+
+// New creates a store keeping responses for ttl. A nil client or non-positive
+// ttl yields a store whose middleware lets everything through.
+func New(rdb *redis.Client, ttl time.Duration) *Store {
+	return &Store{rdb: rdb, ttl: ttl}
+}
+
+// recorder passes the response through while keeping a copy of the body.
+type recorder struct {
+	gin.ResponseWriter
+	buf bytes.Buffer
+}
+
+func (w *recorder) Write(b []byte) (int, error) {
+	w.buf.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recorder) WriteString(s string) (int, error) {
+	w.buf.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Middleware replays the stored response for a repeated Idempotency-Key
+// (keys are scoped per route) and answers 409 while the first request with
+// that key is still running. 5xx responses aren't stored so the client can
+// retry. Requests without the header, and Redis errors, pass through.
+func (s *Store) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		idemKey := c.GetHeader(Header)
+		if s.rdb == nil || s.ttl <= 0 || idemKey == "" {
+			c.Next()
+			return
+		}
+		if len(idemKey) > maxKeyLen {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key too long", "code": "validation_error"})
+			return
+		}
+		key := "idempotency:" + c.Request.Method + ":" + c.FullPath() + ":" + idemKey
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 500*time.Millisecond)
+		claimed, err := s.rdb.SetNX(ctx, key, inFlight, inFlightTTL).Result()
+		if err != nil {
+			cancel()
+			log.Printf("warning: idempotency claim for %s: %v", key, err)
+			c.Next()
+			return
+		}
+		if !claimed {
+			val, err := s.rdb.Get(ctx, key).Bytes()
+			cancel()
+			if err != nil {
+				log.Printf("warning: idempotency lookup for %s: %v", key, err)
+				c.Next()
+				return
+			}
+			s.replay(c, val)
+			return
+		}
+		cancel()
+
+		rec := &recorder{ResponseWriter: c.Writer}
+		c.Writer = rec
+		c.Next()
+		c.Writer = rec.ResponseWriter
+
+		// use a fresh context: the request's may already be cancelled
+		ctx, cancel = context.WithTimeout(context.Background(), 500*time.Millisecond)
+		defer cancel()
+		status := rec.Status()
+		if status >= 500 {
+			if err := s.rdb.Del(ctx, key).Err(); err != nil {
+				log.Printf("warning: idempotency release for %s: %v", key, err)
+			}
+			return
+		}
+		b, err := json.Marshal(storedResponse{
+			Status:      status,
+			ContentType: rec.Header().Get("Content-Type"),
+			Body:        rec.buf.Bytes(),
+		})
+		if err == nil {
+			err = s.rdb.Set(ctx, key, b, s.ttl).Err()
+		}
+		if err != nil {
+			log.Printf("warning: idempotency store for %s: %v", key, err)
+		}
+	}
+}
+
+// replay writes a stored response, or 409 while the original is in flight.
+func (s *Store) replay(c *gin.Context, val []byte) {
+	if string(val) == inFlight {
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "a request with this Idempotency-Key is still being processed", "code": "conflict"})
+		return
+	}
+	var resp storedResponse
+	if err := json.Unmarshal(val, &resp); err != nil {
+		log.Printf("warning: idempotency decode: %v", err)
+		c.Next()
+		return
+	}
+	c.Header("Idempotent-Replayed", "true")
+	c.Data(resp.Status, resp.ContentType, resp.Body)
+	c.Abort()
+}
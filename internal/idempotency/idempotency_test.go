@@ -0,0 +1,203 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// fakeRedis answers SET [NX], GET and DEL from a map without a Redis server
+// (TTLs are ignored). With err set every command fails instead.
+type fakeRedis struct {
+	data map[string]string
+	err  error
+}
+
+func (f *fakeRedis) DialHook(next redis.DialHook) redis.DialHook { return next }
+
+func (f *fakeRedis) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return next
+}
+
+func (f *fakeRedis) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if f.err != nil {
+			cmd.SetErr(f.err)
+			return f.err
+		}
+		args := cmd.Args()
+		key := args[1].(string)
+		switch cmd.Name() {
+		case "set":
+			_, exists := f.data[key]
+			nx := false
+			for _, a := range args[3:] {
+				nx = nx || a == "nx"
+			}
+			if nx && exists {
+				cmd.(*redis.BoolCmd).SetVal(false)
+				return nil
+			}
+			f.data[key] = str(args[2])
+			if c, ok := cmd.(*redis.BoolCmd); ok {
+				c.SetVal(true)
+			} else {
+				cmd.(*redis.StatusCmd).SetVal("OK")
+			}
+		case "get":
+			v, ok := f.data[key]
+			if !ok {
+				cmd.SetErr(redis.Nil)
+				return redis.Nil
+			}
+			cmd.(*redis.StringCmd).SetVal(v)
+		case "del":
+			delete(f.data, key)
+			cmd.(*redis.IntCmd).SetVal(1)
+		}
+		return nil
+	}
+}
+
+func str(v any) string {
+	if b, ok := v.([]byte); ok {
+		return string(b)
+	}
+	return v.(string)
+}
+
+func newFakeClient(f *fakeRedis) *redis.Client {
+	if f.data == nil {
+		f.data = map[string]string{}
+	}
+	rdb := redis.NewClient(&redis.Options{Addr: "redis.invalid:6379"})
+	rdb.AddHook(f)
+	return rdb
+}
+
+// newRouter serves two ingest-like routes behind the middleware, answering
+// with status and counting the requests that reach the handler.
+func newRouter(s *Store, status *int, calls *int) *gin.Engine {
+	r := gin.New()
+	r.Use(s.Middleware())
+	h := func(c *gin.Context) {
+		*calls++
+		c.JSON(*status, gin.H{"call": *calls})
+	}
+	r.POST("/v1/news/ingest", h)
+	r.POST("/v1/news/ingest/feed", h)
+	return r
+}
+
+func post(r http.Handler, path, key string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{}`))
+	if key != "" {
+		req.Header.Set(Header, key)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestReplay(t *testing.T) {
+	status, calls := http.StatusCreated, 0
+	r := newRouter(New(newFakeClient(&fakeRedis{}), time.Hour), &status, &calls)
+
+	first := post(r, "/v1/news/ingest", "abc")
+	again := post(r, "/v1/news/ingest", "abc")
+	if calls != 1 {
+		t.Fatalf("handler ran %d times, want 1", calls)
+	}
+	if again.Code != first.Code || again.Body.String() != first.Body.String() {
+		t.Errorf("replay = %d %s, want %d %s", again.Code, again.Body, first.Code, first.Body)
+	}
+	if ct := again.Header().Get("Content-Type"); ct != first.Header().Get("Content-Type") {
+		t.Errorf("replayed Content-Type = %q, want %q", ct, first.Header().Get("Content-Type"))
+	}
+	if again.Header().Get("Idempotent-Replayed") != "true" {
+		t.Error("replay has no Idempotent-Replayed header")
+	}
+	if first.Header().Get("Idempotent-Replayed") != "" {
+		t.Error("first response is marked as replayed")
+	}
+}
+
+func TestReprocess(t *testing.T) {
+	tests := []struct {
+		name                string
+		status              int
+		firstPath, nextPath string
+		firstKey, nextKey   string
+	}{
+		{"different key", http.StatusCreated, "/v1/news/ingest", "/v1/news/ingest", "a", "b"},
+		{"keys are per route", http.StatusCreated, "/v1/news/ingest", "/v1/news/ingest/feed", "a", "a"},
+		{"no key", http.StatusCreated, "/v1/news/ingest", "/v1/news/ingest", "", ""},
+		{"5xx is not stored", http.StatusInternalServerError, "/v1/news/ingest", "/v1/news/ingest", "a", "a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, calls := tt.status, 0
+			r := newRouter(New(newFakeClient(&fakeRedis{}), time.Hour), &status, &calls)
+			post(r, tt.firstPath, tt.firstKey)
+			if w := post(r, tt.nextPath, tt.nextKey); w.Header().Get("Idempotent-Replayed") != "" {
+				t.Errorf("second request was replayed")
+			}
+			if calls != 2 {
+				t.Errorf("handler ran %d times, want 2", calls)
+			}
+		})
+	}
+}
+
+func TestInFlight(t *testing.T) {
+	f := &fakeRedis{data: map[string]string{"idempotency:POST:/v1/news/ingest:abc": inFlight}}
+	status, calls := http.StatusCreated, 0
+	r := newRouter(New(newFakeClient(f), time.Hour), &status, &calls)
+
+	if w := post(r, "/v1/news/ingest", "abc"); w.Code != http.StatusConflict {
+		t.Errorf("status = %d, want 409 (body %s)", w.Code, w.Body)
+	}
+	if calls != 0 {
+		t.Errorf("handler ran %d times while the key was in flight", calls)
+	}
+}
+
+func TestPassThrough(t *testing.T) {
+	tests := []struct {
+		name      string
+		s         *Store
+		key       string
+		wantCode  int
+		wantCalls int
+	}{
+		{"redis error fails open", New(newFakeClient(&fakeRedis{err: errors.New("connection refused")}), time.Hour), "abc", http.StatusCreated, 2},
+		{"no redis client", New(nil, time.Hour), "abc", http.StatusCreated, 2},
+		{"disabled", New(newFakeClient(&fakeRedis{}), 0), "abc", http.StatusCreated, 2},
+		{"key too long", New(newFakeClient(&fakeRedis{}), time.Hour), strings.Repeat("k", maxKeyLen+1), http.StatusBadRequest, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, calls := http.StatusCreated, 0
+			r := newRouter(tt.s, &status, &calls)
+			for i := 0; i < 2; i++ {
+				if w := post(r, "/v1/news/ingest", tt.key); w.Code != tt.wantCode {
+					t.Fatalf("request %d: status = %d, want %d", i+1, w.Code, tt.wantCode)
+				}
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("handler ran %d times, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package lang
+
+import (
+	"strings"
+
+	"github.com/abadojack/whatlanggo"
+)
+
+// Undetermined is the ISO 639 code stored when the language can't be detected.
+const Undetermined = "und"
+
+// minDetectRunes is the shortest text detection is attempted on; shorter
+// snippets (e.g. a lone headline word) are too ambiguous to classify.
+const minDetectRunes = 12
+
+// minConfidence is the detector confidence below which the result is discarded.
+// whatlanggo's own IsReliable threshold rejects many correct headline-length guesses.
+const minConfidence = 0.5
+
+// Detect returns the ISO 639-1 code of text's language (ISO 639-3 for
+// languages without a two-letter code), or Undetermined when the text is too
+// short or the detector isn't confident enough.
+func Detect(text string) string {
+	text = strings.TrimSpace(text)
+	if len([]rune(text)) < minDetectRunes {
+		return Undetermined
+	}
+	info := whatlanggo.Detect(text)
+	if info.Lang == -1 || info.Confidence < minConfidence {
+		return Undetermined
+	}
+	if code := info.Lang.Iso6391(); code != "" {
+		return code
+	}
+	if code := info.Lang.Iso6393(); code != "" {
+		return code
+	}
+	return Undetermined
+}
+
+// Normalize lowercases and trims a client-supplied language code.
+func Normalize(code string) string {
+	return strings.ToLower(strings.TrimSpace(code))
+}
@@ -1,30 +1,155 @@
 package llm
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
+	"text/template"
 	"time"
+	"unicode"
 )
 
 // Client is a minimal Ollama-compatible LLM client.
 type Client struct {
-	url    string
-	model  string
-	hc     *http.Client
-	logger func(format string, v ...any)
+	url      string
+	model    string
+	hc       *http.Client
+	logger   func(format string, v ...any)
+	observer Observer
+	prompt   *template.Template
+
+	embedURL   string
+	embedModel string
+
+	defaults       GenerateOptions
+	apiStyle       string
+	maxInputTokens int
+	allowedModels  map[string]bool
+	timeout        time.Duration
+	apiKey         string
+	extraHeaders   http.Header
+	fallbackModel  string
+}
+
+// API styles supported by the client (LLM_API_STYLE).
+const (
+	// StyleOllama posts {"model","prompt",...} to /api/generate and reads "response".
+	StyleOllama = "ollama"
+	// StyleOpenAI posts {"model","messages",...} to /v1/chat/completions and reads choices[0].message.content.
+	StyleOpenAI = "openai"
+)
+
+// systemPrompt is the system message sent in OpenAI chat mode.
+const systemPrompt = "You are a concise assistant that summarizes and classifies news articles."
+
+// GenerateOptions tunes a generation request. Zero values mean "not set":
+// a zero MaxTokens falls back to the client default, a nil Temperature is
+// left to the server, an empty Model uses the client's model and an empty
+// Length means LengthMedium.
+type GenerateOptions struct {
+	MaxTokens   int
+	Temperature *float64
+	Model       string
+	// Length picks the summary length (LengthShort/Medium/Long): the prompt's
+	// {{.Length}} wording and, unless MaxTokens is set for the call, the token budget.
+	Length      string
+}
+
+// Summary lengths accepted in GenerateOptions.Length.
+const (
+	LengthShort  = "short"
+	LengthMedium = "medium"
+	LengthLong   = "long"
+)
+
+// lengthInstructions is what {{.Length}} renders to in the summarization prompt.
+var lengthInstructions = map[string]string{
+	LengthShort:  "in one sentence",
+	LengthMedium: "in 2-3 sentences",
+	LengthLong:   "in a paragraph",
+}
+
+// ValidLength reports whether l is a summary length ("" counts as medium).
+func ValidLength(l string) bool {
+	_, ok := lengthInstructions[l]
+	return ok || l == ""
+}
+
+// lengthMaxTokens scales the configured token budget for a summary length:
+// a quarter for short (at least 32), double for long.
+func lengthMaxTokens(maxTokens int, length string) int {
+	switch length {
+	case LengthShort:
+		if maxTokens/4 < 32 {
+			return 32
+		}
+		return maxTokens / 4
+	case LengthLong:
+		return maxTokens * 2
+	}
+	return maxTokens
+}
+
+// defaultTimeout bounds each LLM request unless overridden via SetTimeout (LLM_TIMEOUT).
+const defaultTimeout = 60 * time.Second
+
+// defaultMaxTokens is used when neither the client nor the call sets MaxTokens.
+const defaultMaxTokens = 256
+
+// defaultMaxInputTokens bounds the rendered summarization prompt unless
+// overridden via SetMaxInputTokens (LLM_MAX_INPUT_TOKENS).
+const defaultMaxInputTokens = 4096
+
+// charsPerToken is the rough chars-per-token ratio used by EstimateTokens.
+const charsPerToken = 4
+
+// EstimateTokens approximates the token count of s (about 4 characters per token).
+func EstimateTokens(s string) int {
+	n := len([]rune(s))
+	return (n + charsPerToken - 1) / charsPerToken
+}
+
+// merge returns o with any fields set in override replacing it.
+func (o GenerateOptions) merge(override GenerateOptions) GenerateOptions {
+	if override.MaxTokens > 0 {
+		o.MaxTokens = override.MaxTokens
+	}
+	if override.Temperature != nil {
+		o.Temperature = override.Temperature
+	}
+	if override.Model != "" {
+		o.Model = override.Model
+	}
+	if override.Length != "" {
+		o.Length = override.Length
+	}
+	return o
+}
+
+// Observer receives the outcome of every LLM call (e.g. for metrics).
+// op is "generate", "stream" or "embed".
+type Observer interface {
+	ObserveLLMCall(op string, err error, d time.Duration)
 }
 
-// NewClient creates a new client. If httpClient is nil, a default with timeout is used.
-func NewClient(url, model string, httpClient *http.Client) *Client {
+// NewClient creates a new client. If httpClient is nil, a default one is used.
+// Each request is bounded by the client timeout (60s unless changed via SetTimeout),
+// on top of any deadline the httpClient or the caller's context imposes.
+// promptTemplate is a text/template using {{.Title}} and {{.Content}}; empty means
+// DefaultPromptTemplate. An error is returned if the template doesn't parse.
+func NewClient(url, model, promptTemplate string, httpClient *http.Client) (*Client, error) {
 	if httpClient == nil {
-		httpClient = &http.Client{Timeout: 60 * time.Second}
+		httpClient = &http.Client{}
 	}
-	return &Client{
+	c := &Client{
 		url:   url,
 		model: model,
 		hc:    httpClient,
@@ -32,7 +157,32 @@ func NewClient(url, model string, httpClient *http.Client) *Client {
 			// noop default logger — you can inject one if you want logging.
 			fmt.Fprintf(io.Discard, format, v...)
 		},
+		apiStyle:       StyleOllama,
+		maxInputTokens: defaultMaxInputTokens,
+		timeout:        defaultTimeout,
+	}
+	if err := c.SetPromptTemplate(promptTemplate); err != nil {
+		return nil, err
 	}
+	return c, nil
+}
+
+// DefaultPromptTemplate is the summarization prompt used when none is configured.
+// {{.Length}} is the wording for the requested length (e.g. "in 2-3 sentences").
+const DefaultPromptTemplate = "Summarize the following news article {{.Length}}. Title: {{.Title}}\n\nArticle: {{.Content}}\n\nSummary:"
+
+// SetPromptTemplate replaces the summarization prompt template (empty resets to the default).
+// The template is parsed immediately so mistakes surface at startup, not per request.
+func (c *Client) SetPromptTemplate(tmpl string) error {
+	if tmpl == "" {
+		tmpl = DefaultPromptTemplate
+	}
+	t, err := template.New("prompt").Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return fmt.Errorf("llm prompt template: %w", err)
+	}
+	c.prompt = t
+	return nil
 }
 
 // SetLogger allows injecting a simple printf-like logger for debugging.
@@ -43,42 +193,108 @@ func (c *Client) SetLogger(l func(format string, v ...any)) {
 	c.logger = l
 }
 
-// SummarizeArticleText returns a single clean summary string for the provided title + content.
-// It sends a non-streaming request to the LLM (stream=false) and extracts the returned text.
-func (c *Client) SummarizeArticleText(ctx context.Context, title, content string) (string, error) {
-	prompt := buildPrompt(title, content)
+// SetObserver registers an Observer notified after each LLM call.
+func (c *Client) SetObserver(o Observer) {
+	c.observer = o
+}
 
-	// Build request body tailored for Ollama (model + prompt + max_tokens + stream:false)
-	body := map[string]any{
-		"model":      c.model,
-		"prompt":     prompt,
-		"max_tokens": 256,
-		"stream":     false,
+// observe reports a finished call to the observer, if any.
+func (c *Client) observe(op string, err error, start time.Time) {
+	if c.observer != nil {
+		c.observer.ObserveLLMCall(op, err, time.Since(start))
 	}
-	b, err := json.Marshal(body)
+}
+
+// SummarizeArticleText returns a single clean summary string for the provided title + content
+// and the model that produced it.
+// It sends a non-streaming request to the LLM (stream=false) and extracts the returned text.
+// opts optionally override the client's MaxTokens/Temperature for this call.
+// If the requested model fails and a different fallback model is configured
+// (SetFallbackModel), the request is tried once more with the fallback; the
+// returned error is then the fallback's, wrapping the primary failure too.
+func (c *Client) SummarizeArticleText(ctx context.Context, title, content string, opts ...GenerateOptions) (summary, model string, err error) {
+	o := c.requestOptions(opts)
+	prompt, err := c.summaryPrompt(title, content, o.Length)
+	if err != nil {
+		return "", "", err
+	}
+	summary, err = c.generate(ctx, prompt, o)
+	if err == nil {
+		return summary, o.Model, nil
+	}
+	if c.fallbackModel == "" || c.fallbackModel == o.Model || ctx.Err() != nil {
+		return "", "", err
+	}
+	c.logger("llm model=%s failed (%v), retrying with fallback model=%s", o.Model, err, c.fallbackModel)
+	primaryErr := err
+	o.Model = c.fallbackModel
+	summary, err = c.generate(ctx, prompt, o)
 	if err != nil {
-		return "", fmt.Errorf("llm marshal request: %w", err)
+		return "", "", fmt.Errorf("fallback model %s: %w (primary model: %w)", o.Model, err, primaryErr)
 	}
+	return summary, o.Model, nil
+}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(b))
+// generate sends a non-streaming request for prompt and extracts the returned text.
+func (c *Client) generate(ctx context.Context, prompt string, opts GenerateOptions) (text string, err error) {
+	callStart := time.Now()
+	defer func() { c.observe("generate", err, callStart) }()
+
+	respBody, err := c.generateRaw(ctx, prompt, opts)
 	if err != nil {
-		return "", fmt.Errorf("llm new request: %w", err)
+		return "", err
+	}
+	text, _, err = c.parseResponse(respBody)
+	return text, err
+}
+
+// generateRaw sends a non-streaming request for prompt and returns the response body.
+// On a non-2xx status the body is returned along with the error.
+func (c *Client) generateRaw(ctx context.Context, prompt string, opts GenerateOptions) ([]byte, error) {
+	ctx, cancel := c.requestContext(ctx)
+	defer cancel()
+
+	req, err := c.newGenerateRequest(ctx, prompt, false, opts)
+	if err != nil {
+		return nil, err
 	}
-	req.Header.Set("Content-Type", "application/json")
 
 	start := time.Now()
 	resp, err := c.hc.Do(req)
 	lat := time.Since(start)
-	c.logger("llm request url=%s model=%s status_err=%v latency=%s", c.url, c.model, err, lat)
+	c.logger("llm request url=%s model=%s status_err=%v latency=%s", c.url, opts.Model, err, lat)
 	if err != nil {
-		return "", fmt.Errorf("llm request failed: %w", err)
+		return nil, fmt.Errorf("llm request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	respBody, _ := io.ReadAll(resp.Body)
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		// include body for debugging
-		return "", fmt.Errorf("llm request failed: status=%d body=%s", resp.StatusCode, string(respBody))
+		return respBody, fmt.Errorf("llm request failed: status=%d body=%s", resp.StatusCode, string(respBody))
+	}
+	return respBody, nil
+}
+
+// Parse branches reported by parseResponse (and SummarizeRaw), naming the
+// response shape the text was extracted from.
+const (
+	BranchOpenAIMessage  = "openai.choices.message.content"
+	BranchOpenAIText     = "openai.choices.text"
+	BranchResponse       = "response"
+	BranchText           = "text"
+	BranchChoicesText    = "choices.text"
+	BranchChoicesMessage = "choices.message.content"
+	BranchResults        = "results"
+	BranchNotJSON        = "raw.not_json"
+	BranchFallback       = "raw.fallback"
+)
+
+// parseResponse extracts the generated text from a non-streaming response body
+// and reports which branch matched.
+func (c *Client) parseResponse(respBody []byte) (text, branch string, err error) {
+	if c.apiStyle == StyleOpenAI {
+		return parseOpenAIResponse(respBody)
 	}
 
 	// Try to parse common shapes:
@@ -89,7 +305,7 @@ func (c *Client) SummarizeArticleText(ctx context.Context, title, content string
 	var parsed any
 	if err := json.Unmarshal(respBody, &parsed); err != nil {
 		// not JSON? return raw body
-		return string(respBody), nil
+		return string(respBody), BranchNotJSON, nil
 	}
 
 	// parsed should be object/map
@@ -97,13 +313,13 @@ func (c *Client) SummarizeArticleText(ctx context.Context, title, content string
 		// 1) response
 		if v, ok := m["response"]; ok {
 			if s, ok := v.(string); ok && s != "" {
-				return s, nil
+				return s, BranchResponse, nil
 			}
 		}
 		// 2) text
 		if v, ok := m["text"]; ok {
 			if s, ok := v.(string); ok && s != "" {
-				return s, nil
+				return s, BranchText, nil
 			}
 		}
 		// 3) choices -> first -> text
@@ -112,7 +328,7 @@ func (c *Client) SummarizeArticleText(ctx context.Context, title, content string
 				if first, ok := arr[0].(map[string]any); ok {
 					if t, ok := first["text"]; ok {
 						if s, ok := t.(string); ok && s != "" {
-							return s, nil
+							return s, BranchChoicesText, nil
 						}
 					}
 					// some choices use "message": {"content": "..."}
@@ -120,7 +336,7 @@ func (c *Client) SummarizeArticleText(ctx context.Context, title, content string
 						if m2, ok := msg.(map[string]any); ok {
 							if content, ok := m2["content"]; ok {
 								if s, ok := content.(string); ok && s != "" {
-									return s, nil
+									return s, BranchChoicesMessage, nil
 								}
 							}
 						}
@@ -147,33 +363,616 @@ func (c *Client) SummarizeArticleText(ctx context.Context, title, content string
 					}
 				}
 				if buf != "" {
-					return buf, nil
+					return buf, BranchResults, nil
 				}
 			}
 		}
 	}
 
 	// fallback: return raw body as string (trim)
-	return string(bytes.TrimSpace(respBody)), nil
+	return string(bytes.TrimSpace(respBody)), BranchFallback, nil
 }
 
-// buildPrompt combines title + content into a summarization prompt.
-// Adjust this as you like for style/length.
-func buildPrompt(title, content string) string {
-	// concise instruction + content
-	return fmt.Sprintf("Summarize the following news article in 2-3 sentences. Title: %s\n\nArticle: %s\n\nSummary:", title, content)
+// parseOpenAIResponse extracts choices[0].message.content (or choices[0].text)
+// from a chat completions response.
+func parseOpenAIResponse(body []byte) (string, string, error) {
+	var parsed struct {
+		Choices []struct {
+			Text    string `json:"text"`
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return "", "", fmt.Errorf("llm decode chat response: %w", err)
+	}
+	if len(parsed.Choices) == 0 {
+		return "", "", fmt.Errorf("llm chat response has no choices: %s", string(body))
+	}
+	if content := parsed.Choices[0].Message.Content; content != "" {
+		return content, BranchOpenAIMessage, nil
+	}
+	return parsed.Choices[0].Text, BranchOpenAIText, nil
+}
+
+// RawResult is a summarization call with everything needed to debug it.
+type RawResult struct {
+	Summary string `json:"summary"`
+	Branch  string `json:"parse_branch"`
+	Raw     string `json:"raw"`
+	Prompt  string `json:"prompt"`
+	Model   string `json:"model"`
+}
+
+// SummarizeRaw is the debugging variant of SummarizeArticleText: besides the parsed
+// summary it returns the raw response body, the branch of the parser that matched,
+// and the prompt and model sent. On an LLM error the partial result (e.g. the body
+// of a non-2xx response) is returned with the error.
+func (c *Client) SummarizeRaw(ctx context.Context, title, content string, opts ...GenerateOptions) (res *RawResult, err error) {
+	callStart := time.Now()
+	defer func() { c.observe("generate", err, callStart) }()
+
+	o := c.requestOptions(opts)
+	prompt, err := c.summaryPrompt(title, content, o.Length)
+	if err != nil {
+		return nil, err
+	}
+	res = &RawResult{Prompt: prompt, Model: o.Model}
+	body, err := c.generateRaw(ctx, prompt, o)
+	res.Raw = string(body)
+	if err != nil {
+		return res, err
+	}
+	res.Summary, res.Branch, err = c.parseResponse(body)
+	return res, err
+}
+
+// SummarizeArticleStream is the streaming variant of SummarizeArticleText.
+// It sends stream:true, reads the streamed chunks (Ollama NDJSON objects, or
+// OpenAI "data: {...}" server-sent events) and pushes each text chunk onto out. out is always closed when the method returns.
+// Cancelling ctx aborts the request and stops mid-stream with ctx.Err().
+func (c *Client) SummarizeArticleStream(ctx context.Context, title, content string, out chan<- string, opts ...GenerateOptions) (err error) {
+	defer close(out)
+	callStart := time.Now()
+	defer func() { c.observe("stream", err, callStart) }()
+	ctx, cancel := c.requestContext(ctx)
+	defer cancel()
+
+	o := c.requestOptions(opts)
+	prompt, err := c.summaryPrompt(title, content, o.Length)
+	if err != nil {
+		return err
+	}
+	req, err := c.newGenerateRequest(ctx, prompt, true, o)
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	resp, err := c.hc.Do(req)
+	c.logger("llm stream request url=%s model=%s status_err=%v latency=%s", c.url, o.Model, err, time.Since(start))
+	if err != nil {
+		return fmt.Errorf("llm request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("llm request failed: status=%d body=%s", resp.StatusCode, string(respBody))
+	}
+
+	// bufio.Reader buffers partial lines across reads; ReadBytes only returns
+	// once a full '\n'-terminated line (or EOF) is available.
+	r := bufio.NewReader(resp.Body)
+	for {
+		line, readErr := r.ReadBytes('\n')
+		line = bytes.TrimSpace(line)
+		if len(line) > 0 {
+			text, done, err := c.decodeStreamLine(line)
+			if err != nil {
+				return err
+			}
+			if text != "" {
+				select {
+				case out <- text:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+			if done {
+				return nil
+			}
+		}
+		if readErr != nil {
+			if readErr == io.EOF {
+				return nil
+			}
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("llm stream read: %w", readErr)
+		}
+	}
+}
+
+// decodeStreamLine decodes one non-empty line of a streamed response into its text chunk.
+func (c *Client) decodeStreamLine(line []byte) (text string, done bool, err error) {
+	if c.apiStyle == StyleOpenAI {
+		// server-sent events: "data: {...}" lines, terminated by "data: [DONE]"
+		if !bytes.HasPrefix(line, []byte("data:")) {
+			return "", false, nil
+		}
+		data := bytes.TrimSpace(bytes.TrimPrefix(line, []byte("data:")))
+		if string(data) == "[DONE]" {
+			return "", true, nil
+		}
+		var chunk struct {
+			Choices []struct {
+				Delta struct {
+					Content string `json:"content"`
+				} `json:"delta"`
+				FinishReason *string `json:"finish_reason"`
+			} `json:"choices"`
+		}
+		if err := json.Unmarshal(data, &chunk); err != nil {
+			return "", false, fmt.Errorf("llm stream decode: %w", err)
+		}
+		if len(chunk.Choices) == 0 {
+			return "", false, nil
+		}
+		return chunk.Choices[0].Delta.Content, false, nil
+	}
+
+	var chunk struct {
+		Response string `json:"response"`
+		Done     bool   `json:"done"`
+		Error    string `json:"error"`
+	}
+	if err := json.Unmarshal(line, &chunk); err != nil {
+		return "", false, fmt.Errorf("llm stream decode: %w", err)
+	}
+	if chunk.Error != "" {
+		return "", false, fmt.Errorf("llm stream error: %s", chunk.Error)
+	}
+	return chunk.Response, chunk.Done, nil
+}
+
+// newGenerateRequest builds the POST request in the configured API style.
+//   - ollama: model + prompt + stream, sampling under "options" (num_predict/temperature);
+//     max_tokens is also sent top-level for servers that read it there
+//   - openai: model + messages (system + user) + max_tokens/temperature + stream
+func (c *Client) newGenerateRequest(ctx context.Context, prompt string, stream bool, opts GenerateOptions) (*http.Request, error) {
+	var body map[string]any
+	if c.apiStyle == StyleOpenAI {
+		body = map[string]any{
+			"model": opts.Model,
+			"messages": []map[string]string{
+				{"role": "system", "content": systemPrompt},
+				{"role": "user", "content": prompt},
+			},
+			"max_tokens": opts.MaxTokens,
+			"stream":     stream,
+		}
+		if opts.Temperature != nil {
+			body["temperature"] = *opts.Temperature
+		}
+	} else {
+		ollamaOpts := map[string]any{"num_predict": opts.MaxTokens}
+		body = map[string]any{
+			"model":      opts.Model,
+			"prompt":     prompt,
+			"max_tokens": opts.MaxTokens,
+			"stream":     stream,
+			"options":    ollamaOpts,
+		}
+		if opts.Temperature != nil {
+			ollamaOpts["temperature"] = *opts.Temperature
+		}
+	}
+	b, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("llm marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(b))
+	if err != nil {
+		return nil, fmt.Errorf("llm new request: %w", err)
+	}
+	c.setHeaders(req)
+	return req, nil
+}
+
+// setHeaders sets the JSON content type, any extra headers and the bearer token.
+func (c *Client) setHeaders(req *http.Request) {
+	req.Header.Set("Content-Type", "application/json")
+	for k, vs := range c.extraHeaders {
+		for _, v := range vs {
+			req.Header.Add(k, v)
+		}
+	}
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+}
+
+// SetAPIKey sets a key sent as "Authorization: Bearer <key>" on every request,
+// for gateways that require auth. Empty (the default) sends no Authorization.
+func (c *Client) SetAPIKey(key string) {
+	c.apiKey = key
+}
+
+// SetExtraHeaders sets headers added to every request (e.g. a gateway's
+// tenant or version header). The API key, if set, overrides an Authorization header here.
+func (c *Client) SetExtraHeaders(h http.Header) {
+	c.extraHeaders = h
+}
+
+// ParseHeaders parses comma-separated "Name: value" pairs (LLM_EXTRA_HEADERS).
+// Blank entries are ignored; an entry without a colon or name is an error.
+func ParseHeaders(s string) (http.Header, error) {
+	h := http.Header{}
+	for _, pair := range strings.Split(s, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(pair, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid header %q: want Name:value", strings.TrimSpace(pair))
+		}
+		h.Add(name, strings.TrimSpace(value))
+	}
+	return h, nil
+}
+
+// SetAPIStyle selects the request/response shape: StyleOllama (default) or StyleOpenAI.
+func (c *Client) SetAPIStyle(style string) error {
+	switch style {
+	case "", StyleOllama:
+		c.apiStyle = StyleOllama
+	case StyleOpenAI:
+		c.apiStyle = StyleOpenAI
+	default:
+		return fmt.Errorf("llm: unknown api style %q (want %q or %q)", style, StyleOllama, StyleOpenAI)
+	}
+	return nil
+}
+
+// SetTimeout sets the per-request timeout (for streams, the whole stream).
+// It composes with the caller's context: whichever deadline is earlier wins.
+// 0 disables it and relies on the context alone.
+func (c *Client) SetTimeout(d time.Duration) {
+	c.timeout = d
+}
+
+// requestContext applies the client timeout to ctx. context.WithTimeout keeps
+// an earlier parent deadline, so the shorter of the two always applies.
+func (c *Client) requestContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, c.timeout)
+}
+
+// SetMaxInputTokens sets the estimated token budget for a summarization prompt.
+// Article content is truncated to fit; non-positive values disable truncation.
+func (c *Client) SetMaxInputTokens(n int) {
+	c.maxInputTokens = n
+}
+
+// Model returns the client's default generation model.
+func (c *Client) Model() string {
+	return c.model
+}
+
+// SetFallbackModel sets the model SummarizeArticleText retries with once the
+// requested model fails (empty disables the fallback).
+func (c *Client) SetFallbackModel(model string) {
+	c.fallbackModel = strings.TrimSpace(model)
+}
+
+// FallbackModel returns the configured fallback model, if any.
+func (c *Client) FallbackModel() string {
+	return c.fallbackModel
+}
+
+// SetAllowedModels sets which models callers may select per request via
+// GenerateOptions.Model (see ModelAllowed). The default model is always allowed.
+func (c *Client) SetAllowedModels(models []string) {
+	c.allowedModels = make(map[string]bool, len(models))
+	for _, m := range models {
+		if m = strings.TrimSpace(m); m != "" {
+			c.allowedModels[m] = true
+		}
+	}
+}
+
+// ModelAllowed reports whether model may be requested: empty (meaning the
+// default), the default model itself, or one listed via SetAllowedModels.
+func (c *Client) ModelAllowed(model string) bool {
+	return model == "" || model == c.model || c.allowedModels[model]
+}
+
+// SetGenerateOptions sets the client-wide defaults for MaxTokens and Temperature.
+func (c *Client) SetGenerateOptions(o GenerateOptions) {
+	c.defaults = o
+}
+
+// requestOptions merges per-call overrides over the client defaults. Without a
+// per-call MaxTokens the budget is scaled for the summary length.
+func (c *Client) requestOptions(overrides []GenerateOptions) GenerateOptions {
+	o := GenerateOptions{MaxTokens: defaultMaxTokens, Model: c.model}.merge(c.defaults)
+	explicitMax := false
+	for _, ov := range overrides {
+		o = o.merge(ov)
+		explicitMax = explicitMax || ov.MaxTokens > 0
+	}
+	if !explicitMax {
+		o.MaxTokens = lengthMaxTokens(o.MaxTokens, o.Length)
+	}
+	return o
+}
+
+// SetEmbeddingEndpoint configures the embeddings endpoint (e.g. Ollama's /api/embeddings) and model.
+func (c *Client) SetEmbeddingEndpoint(url, model string) {
+	c.embedURL = url
+	c.embedModel = model
+}
+
+// Embed returns the embedding vector for text.
+// It accepts both Ollama response shapes: {"embedding":[...]} (/api/embeddings)
+// and {"embeddings":[[...]]} (/api/embed).
+func (c *Client) Embed(ctx context.Context, text string) (vec []float32, err error) {
+	callStart := time.Now()
+	defer func() { c.observe("embed", err, callStart) }()
+	ctx, cancel := c.requestContext(ctx)
+	defer cancel()
+
+	if c.embedURL == "" {
+		return nil, fmt.Errorf("llm embed: no embeddings endpoint configured")
+	}
+	b, err := json.Marshal(map[string]any{
+		"model":  c.embedModel,
+		"prompt": text,
+		"input":  text,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("llm marshal request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.embedURL, bytes.NewReader(b))
+	if err != nil {
+		return nil, fmt.Errorf("llm new request: %w", err)
+	}
+	c.setHeaders(req)
+
+	resp, err := c.hc.Do(req)
+	c.logger("llm embed url=%s model=%s status_err=%v latency=%s", c.embedURL, c.embedModel, err, time.Since(callStart))
+	if err != nil {
+		return nil, fmt.Errorf("llm request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("llm request failed: status=%d body=%s", resp.StatusCode, string(respBody))
+	}
+
+	var parsed struct {
+		Embedding  []float32   `json:"embedding"`
+		Embeddings [][]float32 `json:"embeddings"`
+	}
+	if err := json.Unmarshal(respBody, &parsed); err != nil {
+		return nil, fmt.Errorf("llm embed decode: %w", err)
+	}
+	if len(parsed.Embedding) > 0 {
+		return parsed.Embedding, nil
+	}
+	if len(parsed.Embeddings) > 0 && len(parsed.Embeddings[0]) > 0 {
+		return parsed.Embeddings[0], nil
+	}
+	return nil, fmt.Errorf("llm embed: empty embedding in response")
+}
+
+// Taxonomy is the fixed set of categories Categorize may return.
+var Taxonomy = []string{"Technology", "Politics", "Sports", "Business", "Health", "Entertainment", "Other"}
+
+// Categorize asks the LLM to classify title + content into Taxonomy.
+// The model is asked for a JSON array, but small models are chatty, so the
+// reply is parsed defensively: the first [...] block is decoded if possible,
+// otherwise taxonomy names mentioned in the text are used. Unknown labels are
+// dropped; if nothing valid remains the result is ["Other"].
+func (c *Client) Categorize(ctx context.Context, title, content string) ([]string, error) {
+	text, err := c.generate(ctx, buildCategorizePrompt(title, content), c.requestOptions(nil))
+	if err != nil {
+		return nil, err
+	}
+	return parseCategories(text), nil
+}
+
+// parseCategories extracts taxonomy categories from a free-form LLM reply.
+func parseCategories(text string) []string {
+	var labels []string
+	if start, end := strings.Index(text, "["), strings.LastIndex(text, "]"); start >= 0 && end > start {
+		_ = json.Unmarshal([]byte(text[start:end+1]), &labels)
+	}
+	if len(labels) == 0 {
+		// no parseable array: fall back to any taxonomy names mentioned
+		lower := strings.ToLower(text)
+		for _, t := range Taxonomy {
+			if strings.Contains(lower, strings.ToLower(t)) {
+				labels = append(labels, t)
+			}
+		}
+	}
+
+	out := []string{}
+	seen := map[string]bool{}
+	for _, l := range labels {
+		for _, t := range Taxonomy {
+			if strings.EqualFold(strings.TrimSpace(l), t) && !seen[t] {
+				seen[t] = true
+				out = append(out, t)
+			}
+		}
+	}
+	if len(out) == 0 {
+		out = append(out, "Other")
+	}
+	return out
+}
+
+// buildCategorizePrompt asks for a JSON array of categories from Taxonomy.
+func buildCategorizePrompt(title, content string) string {
+	return fmt.Sprintf("Classify the following news article into one or more of these categories: %s. "+
+		"Respond with only a JSON array of category names, for example [\"Technology\"]. Title: %s\n\nArticle: %s\n\nCategories:",
+		strings.Join(Taxonomy, ", "), title, content)
+}
+
+// summaryPrompt renders the summarization prompt, first truncating content so the
+// whole prompt fits the input token budget. Truncation is logged since the
+// summary may then miss the end of the article.
+func (c *Client) summaryPrompt(title, content, length string) (string, error) {
+	if c.maxInputTokens > 0 {
+		overhead, err := c.buildPrompt(title, "", length)
+		if err != nil {
+			return "", err
+		}
+		budget := c.maxInputTokens - EstimateTokens(overhead)
+		if truncated, ok := truncateWords(content, budget*charsPerToken); ok {
+			log.Printf("llm: truncated article content from ~%d to ~%d tokens (title=%q); summary may be lossy",
+				EstimateTokens(content), EstimateTokens(truncated), title)
+			content = truncated
+		}
+	}
+	return c.buildPrompt(title, content, length)
+}
+
+// truncateWords shortens s to at most maxChars characters, cutting at the last
+// whitespace before the limit so words aren't split. It reports whether s was cut.
+func truncateWords(s string, maxChars int) (string, bool) {
+	if maxChars < 0 {
+		maxChars = 0
+	}
+	r := []rune(s)
+	if len(r) <= maxChars {
+		return s, false
+	}
+	cut := r[:maxChars]
+	// only cut mid-word if the text has no whitespace to break at
+	if i := strings.LastIndexFunc(string(cut), unicode.IsSpace); i > 0 {
+		return strings.TrimRightFunc(string(cut)[:i], unicode.IsSpace), true
+	}
+	return string(cut), true
+}
+
+// buildPrompt renders the configured prompt template (LLM_PROMPT_TEMPLATE) with
+// title + content; {{.Length}} becomes the wording for length ("" is medium).
+func (c *Client) buildPrompt(title, content, length string) (string, error) {
+	if length == "" {
+		length = LengthMedium
+	}
+	var buf bytes.Buffer
+	data := struct{ Title, Content, Length string }{Title: title, Content: content, Length: lengthInstructions[length]}
+	if err := c.prompt.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("llm render prompt: %w", err)
+	}
+	return buf.String(), nil
 }
 
 // NewClientFromEnv convenience to create client based on env vars used in docker-compose.
-func NewClientFromEnv() *Client {
+// LLM_PROMPT_TEMPLATE optionally overrides the summarization prompt;
+// LLM_EMBED_URL / LLM_EMBED_MODEL configure embeddings;
+// LLM_MAX_TOKENS / LLM_TEMPERATURE set the generation defaults;
+// LLM_API_STYLE selects "ollama" (default) or "openai" request/response shapes;
+// LLM_MAX_INPUT_TOKENS bounds the summarization prompt (default 4096, <= 0 disables);
+// LLM_ALLOWED_MODELS lists extra models callers may pick per request (comma-separated);
+// LLM_FALLBACK_MODEL is tried once when a summarization with the requested model fails;
+// LLM_TIMEOUT bounds each request (a duration like 90s, or seconds; default 60s, 0 = context only);
+// LLM_API_KEY is sent as a bearer token and LLM_EXTRA_HEADERS (comma-separated Name:value)
+// on every request, for gateways needing them; both are optional.
+func NewClientFromEnv() (*Client, error) {
 	url := os.Getenv("LLM_URL")
 	model := os.Getenv("LLM_MODEL")
-	// if url is empty default to localhost ollama endpoint
+	style := os.Getenv("LLM_API_STYLE")
+	// if url is empty default to localhost ollama endpoint (or its OpenAI-compatible route)
 	if url == "" {
 		url = "http://host.docker.internal:11434/api/generate"
+		if style == StyleOpenAI {
+			url = "http://host.docker.internal:11434/v1/chat/completions"
+		}
 	}
 	if model == "" {
 		model = "smollm2:135m"
 	}
-	return NewClient(url, model, nil)
+	c, err := NewClient(url, model, os.Getenv("LLM_PROMPT_TEMPLATE"), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	embedURL := os.Getenv("LLM_EMBED_URL")
+	embedModel := os.Getenv("LLM_EMBED_MODEL")
+	// default to the embeddings endpoint next to the generate endpoint
+	if embedURL == "" {
+		embedURL = strings.Replace(url, "/api/generate", "/api/embeddings", 1)
+	}
+	if embedModel == "" {
+		embedModel = "nomic-embed-text"
+	}
+	c.SetEmbeddingEndpoint(embedURL, embedModel)
+	if err := c.SetAPIStyle(style); err != nil {
+		return nil, err
+	}
+
+	var gen GenerateOptions
+	if v := os.Getenv("LLM_MAX_TOKENS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid LLM_MAX_TOKENS=%q", v)
+		}
+		gen.MaxTokens = n
+	}
+	if v := os.Getenv("LLM_TEMPERATURE"); v != "" {
+		t, err := strconv.ParseFloat(v, 64)
+		if err != nil || t < 0 {
+			return nil, fmt.Errorf("invalid LLM_TEMPERATURE=%q", v)
+		}
+		gen.Temperature = &t
+	}
+	c.SetGenerateOptions(gen)
+
+	if v := os.Getenv("LLM_MAX_INPUT_TOKENS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid LLM_MAX_INPUT_TOKENS=%q", v)
+		}
+		c.SetMaxInputTokens(n)
+	}
+	if v := os.Getenv("LLM_TIMEOUT"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			secs, aerr := strconv.Atoi(v)
+			if aerr != nil {
+				return nil, fmt.Errorf("invalid LLM_TIMEOUT=%q", v)
+			}
+			d = time.Duration(secs) * time.Second
+		}
+		if d < 0 {
+			return nil, fmt.Errorf("invalid LLM_TIMEOUT=%q", v)
+		}
+		c.SetTimeout(d)
+	}
+	if v := os.Getenv("LLM_ALLOWED_MODELS"); v != "" {
+		c.SetAllowedModels(strings.Split(v, ","))
+	}
+	c.SetFallbackModel(os.Getenv("LLM_FALLBACK_MODEL"))
+	c.SetAPIKey(strings.TrimSpace(os.Getenv("LLM_API_KEY")))
+	if v := os.Getenv("LLM_EXTRA_HEADERS"); v != "" {
+		h, err := ParseHeaders(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid LLM_EXTRA_HEADERS: %w", err)
+		}
+		c.SetExtraHeaders(h)
+	}
+	return c, nil
 }
\ No newline at end of file
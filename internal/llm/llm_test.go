@@ -0,0 +1,364 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newTestClient returns a client pointed at srv in the given API style.
+func newTestClient(t *testing.T, srv *httptest.Server, style string) *Client {
+	t.Helper()
+	c, err := NewClient(srv.URL, "test-model", "", srv.Client())
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	if err := c.SetAPIStyle(style); err != nil {
+		t.Fatalf("SetAPIStyle: %v", err)
+	}
+	return c
+}
+
+// collect runs SummarizeArticleStream and returns the chunks it sent.
+func collect(ctx context.Context, c *Client) ([]string, error) {
+	out := make(chan string)
+	errc := make(chan error, 1)
+	go func() { errc <- c.SummarizeArticleStream(ctx, "title", "content", out) }()
+	var chunks []string
+	for s := range out {
+		chunks = append(chunks, s)
+	}
+	return chunks, <-errc
+}
+
+func TestDecodeStreamLine(t *testing.T) {
+	tests := []struct {
+		name     string
+		style    string
+		line     string
+		wantText string
+		wantDone bool
+		wantErr  bool
+	}{
+		{"ollama chunk", StyleOllama, `{"response":"Hel","done":false}`, "Hel", false, false},
+		{"ollama done", StyleOllama, `{"response":"","done":true}`, "", true, false},
+		{"ollama error", StyleOllama, `{"error":"model not found"}`, "", false, true},
+		{"ollama bad json", StyleOllama, `{"response":`, "", false, true},
+		{"openai chunk", StyleOpenAI, `data: {"choices":[{"delta":{"content":"lo"}}]}`, "lo", false, false},
+		{"openai done", StyleOpenAI, `data: [DONE]`, "", true, false},
+		{"openai no choices", StyleOpenAI, `data: {"choices":[]}`, "", false, false},
+		{"openai comment", StyleOpenAI, `: keep-alive`, "", false, false},
+		{"openai bad json", StyleOpenAI, `data: {"choices":`, "", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Client{apiStyle: tt.style}
+			text, done, err := c.decodeStreamLine([]byte(tt.line))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if text != tt.wantText || done != tt.wantDone {
+				t.Errorf("decodeStreamLine = %q, %v, want %q, %v", text, done, tt.wantText, tt.wantDone)
+			}
+		})
+	}
+}
+
+func TestSummarizeArticleStream(t *testing.T) {
+	tests := []struct {
+		name    string
+		style   string
+		writes  []string // each write is flushed separately
+		want    []string
+		wantErr bool
+	}{
+		{
+			"ollama ndjson", StyleOllama,
+			[]string{"{\"response\":\"Hel\"}\n{\"response\":\"lo\"}\n{\"response\":\"\",\"done\":true}\n"},
+			[]string{"Hel", "lo"}, false,
+		},
+		{
+			"line split across writes", StyleOllama,
+			[]string{`{"respo`, "nse\":\"Hel\"}\n{\"response\":", "\"lo\",\"done\":true}\n"},
+			[]string{"Hel", "lo"}, false,
+		},
+		{
+			"blank lines and no trailing newline", StyleOllama,
+			[]string{"\n{\"response\":\"Hel\"}\r\n\n", `{"response":"lo"}`},
+			[]string{"Hel", "lo"}, false,
+		},
+		{
+			"stops at done", StyleOllama,
+			[]string{"{\"response\":\"Hel\",\"done\":true}\n{\"response\":\"ignored\"}\n"},
+			[]string{"Hel"}, false,
+		},
+		{
+			"error mid-stream", StyleOllama,
+			[]string{"{\"response\":\"Hel\"}\n{\"error\":\"out of memory\"}\n"},
+			[]string{"Hel"}, true,
+		},
+		{
+			"openai events", StyleOpenAI,
+			[]string{"data: {\"choices\":[{\"delta\":{\"content\":\"Hel\"}}]}\n\n", "data: {\"choices\":[{\"delta\":{\"content\":\"lo\"}}]}\n\ndata: [DONE]\n\n"},
+			[]string{"Hel", "lo"}, false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				for _, s := range tt.writes {
+					w.Write([]byte(s))
+					w.(http.Flusher).Flush()
+				}
+			}))
+			defer srv.Close()
+
+			got, err := collect(context.Background(), newTestClient(t, srv, tt.style))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("chunks = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSummarizeArticleStreamStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "no such model", http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	got, err := collect(context.Background(), newTestClient(t, srv, StyleOllama))
+	if err == nil || !strings.Contains(err.Error(), "status=404") {
+		t.Errorf("error = %v, want a status=404 error", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("chunks = %q, want none", got)
+	}
+}
+
+func TestSummarizeArticleStreamCancel(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("{\"response\":\"Hel\"}\n"))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done() // never finish; wait for the client to go away
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c := newTestClient(t, srv, StyleOllama)
+	out := make(chan string)
+	errc := make(chan error, 1)
+	go func() { errc <- c.SummarizeArticleStream(ctx, "title", "content", out) }()
+
+	if first := <-out; first != "Hel" {
+		t.Fatalf("first chunk = %q, want %q", first, "Hel")
+	}
+	cancel()
+	for range out {
+	}
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Errorf("error = %v, want context.Canceled", err)
+	}
+}
+
+func TestRequestBodyShape(t *testing.T) {
+	temp := 0.2
+	tests := []struct {
+		name     string
+		style    string
+		wantKeys []string
+		noKeys   []string
+	}{
+		{"ollama", StyleOllama, []string{"model", "prompt", "stream", "options"}, []string{"messages"}},
+		{"openai", StyleOpenAI, []string{"model", "messages", "stream", "max_tokens", "temperature"}, []string{"prompt", "options"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body map[string]any
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					t.Errorf("decode request: %v", err)
+				}
+				if tt.style == StyleOpenAI {
+					w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"A summary."}}]}`))
+				} else {
+					w.Write([]byte(`{"response":"A summary."}`))
+				}
+			}))
+			defer srv.Close()
+
+			c := newTestClient(t, srv, tt.style)
+			summary, _, err := c.SummarizeArticleText(context.Background(), "title", "content", GenerateOptions{Temperature: &temp})
+			if err != nil {
+				t.Fatalf("SummarizeArticleText: %v", err)
+			}
+			if summary != "A summary." {
+				t.Errorf("summary = %q, want %q", summary, "A summary.")
+			}
+			for _, k := range tt.wantKeys {
+				if _, ok := body[k]; !ok {
+					t.Errorf("request body %v has no %q", body, k)
+				}
+			}
+			for _, k := range tt.noKeys {
+				if _, ok := body[k]; ok {
+					t.Errorf("request body %v has %q", body, k)
+				}
+			}
+			if body["stream"] != false {
+				t.Errorf("stream = %v, want false", body["stream"])
+			}
+			if tt.style != StyleOpenAI {
+				return
+			}
+			msgs, _ := body["messages"].([]any)
+			if len(msgs) != 2 {
+				t.Fatalf("messages = %v, want system and user", body["messages"])
+			}
+			for i, role := range []string{"system", "user"} {
+				m, _ := msgs[i].(map[string]any)
+				if m["role"] != role || m["content"] == "" {
+					t.Errorf("messages[%d] = %v, want a %s message", i, msgs[i], role)
+				}
+			}
+			if user, _ := msgs[1].(map[string]any); !strings.Contains(user["content"].(string), "content") {
+				t.Errorf("user message %q does not contain the article", user["content"])
+			}
+		})
+	}
+}
+
+func TestParseOpenAIResponse(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		want       string
+		wantBranch string
+		wantErr    bool
+	}{
+		{"message content", `{"choices":[{"message":{"role":"assistant","content":"Hi."}}]}`, "Hi.", BranchOpenAIMessage, false},
+		{"first choice wins", `{"choices":[{"message":{"content":"one"}},{"message":{"content":"two"}}]}`, "one", BranchOpenAIMessage, false},
+		{"falls back to text", `{"choices":[{"text":"Hi."}]}`, "Hi.", BranchOpenAIText, false},
+		{"no choices", `{"choices":[]}`, "", "", true},
+		{"not json", `Internal Server Error`, "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, branch, err := parseOpenAIResponse([]byte(tt.body))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want || branch != tt.wantBranch {
+				t.Errorf("parseOpenAIResponse = %q, %q, want %q, %q", got, branch, tt.want, tt.wantBranch)
+			}
+		})
+	}
+}
+
+func TestParseResponse(t *testing.T) {
+	tests := []struct {
+		name       string
+		style      string
+		body       string
+		want       string
+		wantBranch string
+	}{
+		{"ollama response", StyleOllama, `{"response":"Hi.","done":true}`, "Hi.", BranchResponse},
+		{"text field", StyleOllama, `{"text":"Hi."}`, "Hi.", BranchText},
+		{"choices text", StyleOllama, `{"choices":[{"text":"Hi."}]}`, "Hi.", BranchChoicesText},
+		{"choices message", StyleOllama, `{"choices":[{"message":{"content":"Hi."}}]}`, "Hi.", BranchChoicesMessage},
+		{"results", StyleOllama, `{"results":[{"response":"Hi"},{"text":"."}]}`, "Hi.", BranchResults},
+		{"not json", StyleOllama, `Hi.`, "Hi.", BranchNotJSON},
+		{"unknown shape falls back to the body", StyleOllama, ` {"output":"Hi."} `, `{"output":"Hi."}`, BranchFallback},
+		{"openai style", StyleOpenAI, `{"choices":[{"message":{"content":"Hi."}}]}`, "Hi.", BranchOpenAIMessage},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Client{apiStyle: tt.style}
+			got, branch, err := c.parseResponse([]byte(tt.body))
+			if err != nil {
+				t.Fatalf("parseResponse: %v", err)
+			}
+			if got != tt.want || branch != tt.wantBranch {
+				t.Errorf("parseResponse = %q, %q, want %q, %q", got, branch, tt.want, tt.wantBranch)
+			}
+		})
+	}
+}
+
+func TestSetAPIStyle(t *testing.T) {
+	c := &Client{}
+	for _, style := range []string{"", StyleOllama, StyleOpenAI} {
+		if err := c.SetAPIStyle(style); err != nil {
+			t.Errorf("SetAPIStyle(%q): %v", style, err)
+		}
+	}
+	if err := c.SetAPIStyle("anthropic"); err == nil {
+		t.Error("SetAPIStyle(anthropic) succeeded")
+	}
+}
+
+func TestSummarizeFallbackModel(t *testing.T) {
+	tests := []struct {
+		name      string
+		fail      map[string]bool // models the server rejects
+		fallback  string
+		wantModel string
+		wantCalls int
+		wantErr   bool
+	}{
+		{"primary answers", nil, "small", "primary", 1, false},
+		{"falls back", map[string]bool{"primary": true}, "small", "small", 2, false},
+		{"no fallback configured", map[string]bool{"primary": true}, "", "", 1, true},
+		{"both fail", map[string]bool{"primary": true, "small": true}, "small", "", 2, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				var req struct {
+					Model string `json:"model"`
+				}
+				json.NewDecoder(r.Body).Decode(&req)
+				if tt.fail[req.Model] {
+					http.Error(w, req.Model+" overloaded", http.StatusServiceUnavailable)
+					return
+				}
+				json.NewEncoder(w).Encode(map[string]string{"response": "by " + req.Model})
+			}))
+			defer srv.Close()
+
+			c, err := NewClient(srv.URL, "primary", "", srv.Client())
+			if err != nil {
+				t.Fatalf("NewClient: %v", err)
+			}
+			c.SetFallbackModel(tt.fallback)
+			summary, model, err := c.SummarizeArticleText(context.Background(), "title", "content")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("%d requests, want %d", calls, tt.wantCalls)
+			}
+			if tt.wantErr {
+				// the primary failure stays visible behind the fallback's
+				if !strings.Contains(err.Error(), "primary overloaded") {
+					t.Errorf("error %q does not mention the primary failure", err)
+				}
+				return
+			}
+			if model != tt.wantModel || summary != "by "+tt.wantModel {
+				t.Errorf("SummarizeArticleText = %q, %q, want a summary by %s", summary, model, tt.wantModel)
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+// Package logging sets up the service's structured logger and the gin
+// middlewares that log requests (and, when debugging, their bodies).
+package logging
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ParseLevel parses LOG_LEVEL: debug, info, warn (or warning) or error,
+// case-insensitive. Empty means info.
+func ParseLevel(s string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return 0, fmt.Errorf("unknown log level %q (want debug, info, warn or error)", s)
+}
+
+// New returns a JSON logger writing records at level or above to stderr.
+// Installing it with slog.SetDefault also routes the standard log package
+// through it (at info level).
+func New(level slog.Level) *slog.Logger {
+	return slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: level}))
+}
+
+// RequestLogger replaces gin's default access log: one record per request with
+// method, path, status, latency and client IP, at error level for 5xx, warn for
+// 4xx and info otherwise, so LOG_LEVEL=warn keeps only failed requests.
+func RequestLogger(logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= 500:
+			level = slog.LevelError
+		case status >= 400:
+			level = slog.LevelWarn
+		}
+		ctx := c.Request.Context()
+		if !logger.Enabled(ctx, level) {
+			return
+		}
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Duration("latency", time.Since(start)),
+			slog.String("client_ip", c.ClientIP()),
+			slog.Int("bytes", c.Writer.Size()),
+		}
+		if errs := c.Errors.ByType(gin.ErrorTypePrivate).String(); errs != "" {
+			attrs = append(attrs, slog.String("errors", errs))
+		}
+		logger.LogAttrs(ctx, level, "request", attrs...)
+	}
+}
+
+// BodyLogger logs the first maxBytes of each request and response body at
+// debug level, with the values of the given JSON fields replaced by
+// "[REDACTED]". It does nothing unless the logger has debug enabled. The
+// request body is captured as the handler reads it, so streamed bodies are
+// neither buffered nor delayed.
+func BodyLogger(logger *slog.Logger, maxBytes int, redactFields []string) gin.HandlerFunc {
+	redact := redactor(redactFields)
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		if maxBytes <= 0 || !logger.Enabled(ctx, slog.LevelDebug) {
+			c.Next()
+			return
+		}
+		reqBody := &capture{max: maxBytes}
+		if c.Request.Body != nil {
+			c.Request.Body = &captureReader{ReadCloser: c.Request.Body, capture: reqBody}
+		}
+		respBody := &capture{max: maxBytes}
+		c.Writer = &captureWriter{ResponseWriter: c.Writer, capture: respBody}
+
+		c.Next()
+
+		logger.LogAttrs(ctx, slog.LevelDebug, "request body",
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.Int("status", c.Writer.Status()),
+			slog.String("request_body", redact(reqBody.String())),
+			slog.Bool("request_truncated", reqBody.truncated),
+			slog.String("response_body", redact(respBody.String())),
+			slog.Bool("response_truncated", respBody.truncated),
+		)
+	}
+}
+
+// redactor returns a func masking the string, number, boolean or null values of
+// the given JSON keys (case-insensitive). It works on truncated JSON too.
+func redactor(fields []string) func(string) string {
+	names := []string{}
+	for _, f := range fields {
+		if f = strings.TrimSpace(f); f != "" {
+			names = append(names, regexp.QuoteMeta(f))
+		}
+	}
+	if len(names) == 0 {
+		return func(s string) string { return s }
+	}
+	re := regexp.MustCompile(`("(?i:` + strings.Join(names, "|") + `)"\s*:\s*)("(?:[^"\\]|\\.)*"?|[^,}\]\s]+)`)
+	return func(s string) string {
+		return re.ReplaceAllString(s, `$1"[REDACTED]"`)
+	}
+}
+
+// capture keeps the first max bytes written to it and notes whether more followed.
+type capture struct {
+	buf       bytes.Buffer
+	max       int
+	truncated bool
+}
+
+func (c *capture) add(p []byte) {
+	if room := c.max - c.buf.Len(); room < len(p) {
+		c.truncated = true
+		p = p[:max(room, 0)]
+	}
+	c.buf.Write(p)
+}
+
+func (c *capture) String() string { return c.buf.String() }
+
+// captureReader copies what the handler reads from the request body into a capture.
+type captureReader struct {
+	io.ReadCloser
+	capture *capture
+}
+
+func (r *captureReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.capture.add(p[:n])
+	return n, err
+}
+
+// captureWriter copies the response body into a capture as it is written.
+type captureWriter struct {
+	gin.ResponseWriter
+	capture *capture
+}
+
+func (w *captureWriter) Write(p []byte) (int, error) {
+	w.capture.add(p)
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *captureWriter) WriteString(s string) (int, error) {
+	w.capture.add([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,79 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Metrics holds the Prometheus collectors for HTTP handlers and LLM calls.
+// Collectors are registered on the registry passed to New, so tests can use
+// their own prometheus.NewRegistry() and inspect it.
+type Metrics struct {
+	httpRequests *prometheus.CounterVec
+	httpDuration *prometheus.HistogramVec
+	llmCalls     *prometheus.CounterVec
+	llmDuration  *prometheus.HistogramVec
+}
+
+// New creates the collectors and registers them on reg.
+func New(reg prometheus.Registerer) *Metrics {
+	m := &Metrics{
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "HTTP requests by route, method and status.",
+		}, []string{"route", "method", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "HTTP request latency by route, method and status.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"route", "method", "status"}),
+		llmCalls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "llm_calls_total",
+			Help: "LLM calls by operation and result (success/error).",
+		}, []string{"op", "result"}),
+		llmDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "llm_call_duration_seconds",
+			Help:    "LLM call latency by operation and result.",
+			Buckets: []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120},
+		}, []string{"op", "result"}),
+	}
+	reg.MustRegister(m.httpRequests, m.httpDuration, m.llmCalls, m.llmDuration)
+	return m
+}
+
+// Middleware records request count and duration for every request.
+// The route label is the registered pattern (e.g. /v1/news/:id) to keep cardinality bounded.
+func (m *Metrics) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		status := strconv.Itoa(c.Writer.Status())
+		m.httpRequests.WithLabelValues(route, c.Request.Method, status).Inc()
+		m.httpDuration.WithLabelValues(route, c.Request.Method, status).Observe(time.Since(start).Seconds())
+	}
+}
+
+// ObserveLLMCall implements llm.Observer.
+func (m *Metrics) ObserveLLMCall(op string, err error, d time.Duration) {
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	m.llmCalls.WithLabelValues(op, result).Inc()
+	m.llmDuration.WithLabelValues(op, result).Observe(d.Seconds())
+}
+
+// Handler serves the metrics gathered from g in the Prometheus text format.
+func Handler(g prometheus.Gatherer) http.Handler {
+	return promhttp.HandlerFor(g, promhttp.HandlerOpts{})
+}
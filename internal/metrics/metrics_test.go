@@ -0,0 +1,128 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/nitesh/news_service/internal/llm"
+)
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	m := New(prometheus.NewRegistry())
+	r := gin.New()
+	r.Use(m.Middleware())
+	r.GET("/v1/news/:id", func(c *gin.Context) {
+		if c.Param("id") == "missing" {
+			c.Status(http.StatusNotFound)
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+
+	for _, path := range []string{"/v1/news/a", "/v1/news/b", "/v1/news/missing", "/nope"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	tests := []struct {
+		route, status string
+		want          float64
+	}{
+		{"/v1/news/:id", "200", 2},
+		{"/v1/news/:id", "404", 1},
+		{"unmatched", "404", 1},
+	}
+	for _, tt := range tests {
+		if got := testutil.ToFloat64(m.httpRequests.WithLabelValues(tt.route, http.MethodGet, tt.status)); got != tt.want {
+			t.Errorf("http_requests_total{route=%q,status=%q} = %v, want %v", tt.route, tt.status, got, tt.want)
+		}
+	}
+	if got := testutil.CollectAndCount(m.httpDuration); got != len(tests) {
+		t.Errorf("http_request_duration_seconds has %d series, want %d", got, len(tests))
+	}
+}
+
+func TestObserveLLMCall(t *testing.T) {
+	m := New(prometheus.NewRegistry())
+	m.ObserveLLMCall("generate", nil, time.Second)
+	m.ObserveLLMCall("generate", errors.New("boom"), time.Second)
+	m.ObserveLLMCall("embed", nil, time.Second)
+
+	tests := []struct {
+		op, result string
+		want       float64
+	}{
+		{"generate", "success", 1},
+		{"generate", "error", 1},
+		{"embed", "success", 1},
+		{"embed", "error", 0},
+	}
+	for _, tt := range tests {
+		if got := testutil.ToFloat64(m.llmCalls.WithLabelValues(tt.op, tt.result)); got != tt.want {
+			t.Errorf("llm_calls_total{op=%q,result=%q} = %v, want %v", tt.op, tt.result, got, tt.want)
+		}
+	}
+}
+
+// TestLLMClientObserver checks the counters move when a real llm.Client
+// reports its calls to Metrics.
+func TestLLMClientObserver(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"response":"a summary"}`))
+	}))
+	defer srv.Close()
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "model not loaded", http.StatusInternalServerError)
+	}))
+	defer down.Close()
+
+	m := New(prometheus.NewRegistry())
+	for _, tt := range []struct {
+		url     string
+		wantErr bool
+	}{
+		{srv.URL, false},
+		{down.URL, true},
+	} {
+		c, err := llm.NewClient(tt.url, "test-model", "", srv.Client())
+		if err != nil {
+			t.Fatalf("NewClient: %v", err)
+		}
+		c.SetObserver(m)
+		_, _, err = c.SummarizeArticleText(context.Background(), "Title", "Body")
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("SummarizeArticleText(%s) error = %v, want error %v", tt.url, err, tt.wantErr)
+		}
+	}
+
+	if got := testutil.ToFloat64(m.llmCalls.WithLabelValues("generate", "success")); got != 1 {
+		t.Errorf("generate successes = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(m.llmCalls.WithLabelValues("generate", "error")); got != 1 {
+		t.Errorf("generate errors = %v, want 1", got)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m := New(reg)
+	m.ObserveLLMCall("generate", nil, time.Second)
+
+	w := httptest.NewRecorder()
+	Handler(reg).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+	if want := `llm_calls_total{op="generate",result="success"} 1`; !strings.Contains(w.Body.String(), want) {
+		t.Errorf("metrics output missing %q", want)
+	}
+}
@@ -0,0 +1,89 @@
+package ratelimit
+
+import (
+	"context"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// tokenBucket refills at ARGV[1] tokens/sec up to ARGV[2] and takes one token
+// per call. It returns {allowed (0/1), wait in ms until a token is available}.
+// State lives in a hash so concurrent replicas share the same bucket.
+var tokenBucket = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local state = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(state[1]) or burst
+local ts = tonumber(state[2]) or now
+tokens = math.min(burst, tokens + math.max(0, now - ts) / 1000 * rate)
+local allowed = 0
+local wait = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+else
+  wait = math.ceil((1 - tokens) / rate * 1000)
+end
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "ts", now)
+redis.call("PEXPIRE", KEYS[1], math.ceil(burst / rate * 1000) + 1000)
+return {allowed, wait}
+`)
+
+// Limiter is a Redis-backed token bucket keyed by client IP and route.
+type Limiter struct {
+	rdb   *redis.Client
+	rps   float64
+	burst int
+}
+
+// New creates a limiter allowing rps requests per second with bursts of up to burst.
+// A nil client or non-positive rps yields a limiter that lets everything through.
+func New(rdb *redis.Client, rps float64, burst int) *Limiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &Limiter{rdb: rdb, rps: rps, burst: burst}
+}
+
+// Middleware rejects requests over the limit with 429 and a Retry-After header.
+// Redis errors fail open so a cache outage doesn't take the API down.
+func (l *Limiter) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if l.rdb == nil || l.rps <= 0 {
+			c.Next()
+			return
+		}
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		key := "ratelimit:" + route + ":" + c.ClientIP()
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 500*time.Millisecond)
+		res, err := tokenBucket.Run(ctx, l.rdb, []string{key}, l.rps, l.burst, time.Now().UnixMilli()).Int64Slice()
+		cancel()
+		if err != nil || len(res) != 2 {
+			log.Printf("warning: rate limit check failed for %s: %v", key, err)
+			c.Next()
+			return
+		}
+		if res[0] == 1 {
+			c.Next()
+			return
+		}
+
+		retry := int(math.Ceil(float64(res[1]) / 1000))
+		if retry < 1 {
+			retry = 1
+		}
+		c.Header("Retry-After", strconv.Itoa(retry))
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "rate limit exceeded", "code": "rate_limited"})
+	}
+}
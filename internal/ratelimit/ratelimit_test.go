@@ -0,0 +1,149 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// fakeBucket answers the token bucket script without a Redis server: each key
+// gets burst calls, after which it is limited with a 1.5s wait. With err set
+// every command fails instead.
+type fakeBucket struct {
+	burst int
+	err   error
+	calls map[string]int
+}
+
+func (f *fakeBucket) DialHook(next redis.DialHook) redis.DialHook { return next }
+
+func (f *fakeBucket) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return next
+}
+
+func (f *fakeBucket) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if f.err != nil {
+			cmd.SetErr(f.err)
+			return f.err
+		}
+		// evalsha <sha> <numkeys> <key> <argv...>
+		key := cmd.Args()[3].(string)
+		f.calls[key]++
+		if f.calls[key] <= f.burst {
+			cmd.(*redis.Cmd).SetVal([]any{int64(1), int64(0)})
+		} else {
+			cmd.(*redis.Cmd).SetVal([]any{int64(0), int64(1500)})
+		}
+		return nil
+	}
+}
+
+func newFakeClient(f *fakeBucket) *redis.Client {
+	f.calls = map[string]int{}
+	rdb := redis.NewClient(&redis.Options{Addr: "redis.invalid:6379"})
+	rdb.AddHook(f)
+	return rdb
+}
+
+// newRouter serves a limited route, trusting X-Forwarded-For from proxies.
+func newRouter(t *testing.T, l *Limiter, proxies []string) *gin.Engine {
+	t.Helper()
+	r := gin.New()
+	if err := r.SetTrustedProxies(proxies); err != nil {
+		t.Fatalf("SetTrustedProxies: %v", err)
+	}
+	r.Use(l.Middleware())
+	r.POST("/v1/news/:id/summary", func(c *gin.Context) { c.Status(http.StatusOK) })
+	return r
+}
+
+func request(r http.Handler, remoteAddr, forwardedFor string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/v1/news/1/summary", nil)
+	req.RemoteAddr = net.JoinHostPort(remoteAddr, "40000")
+	if forwardedFor != "" {
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestMiddleware(t *testing.T) {
+	f := &fakeBucket{burst: 2}
+	r := newRouter(t, New(newFakeClient(f), 1, 2), nil)
+
+	for i, want := range []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+		w := request(r, "203.0.113.7", "")
+		if w.Code != want {
+			t.Fatalf("request %d: status = %d, want %d", i+1, w.Code, want)
+		}
+		if want == http.StatusTooManyRequests && w.Header().Get("Retry-After") != "2" {
+			t.Errorf("Retry-After = %q, want 2", w.Header().Get("Retry-After"))
+		}
+	}
+	if w := request(r, "203.0.113.8", ""); w.Code != http.StatusOK {
+		t.Errorf("another client: status = %d, want 200", w.Code)
+	}
+	if _, ok := f.calls["ratelimit:/v1/news/:id/summary:203.0.113.7"]; !ok {
+		t.Errorf("bucket keys %v are not keyed by route and client IP", f.calls)
+	}
+}
+
+// TestMiddlewareForwardedFor checks clients can't pick their own bucket: a
+// spoofed X-Forwarded-For only counts when the peer is a trusted proxy.
+func TestMiddlewareForwardedFor(t *testing.T) {
+	tests := []struct {
+		name       string
+		proxies    []string
+		remoteAddr string
+		wantKeys   int
+	}{
+		{"untrusted peer", []string{"10.0.0.0/8"}, "203.0.113.7", 1},
+		{"no trusted proxies", nil, "10.0.0.1", 1},
+		{"trusted proxy", []string{"10.0.0.0/8"}, "10.0.0.1", 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeBucket{burst: 1}
+			r := newRouter(t, New(newFakeClient(f), 1, 1), tt.proxies)
+			for _, xff := range []string{"198.51.100.1", "198.51.100.2", "198.51.100.3"} {
+				request(r, tt.remoteAddr, xff)
+			}
+			if len(f.calls) != tt.wantKeys {
+				t.Errorf("requests used %d buckets %v, want %d", len(f.calls), f.calls, tt.wantKeys)
+			}
+		})
+	}
+}
+
+func TestMiddlewarePassThrough(t *testing.T) {
+	tests := []struct {
+		name string
+		l    *Limiter
+	}{
+		{"redis error fails open", New(newFakeClient(&fakeBucket{err: errors.New("connection refused")}), 1, 1)},
+		{"no redis client", New(nil, 1, 1)},
+		{"disabled", New(newFakeClient(&fakeBucket{}), 0, 1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newRouter(t, tt.l, nil)
+			for i := 0; i < 3; i++ {
+				if w := request(r, "203.0.113.7", ""); w.Code != http.StatusOK {
+					t.Fatalf("request %d: status = %d, want 200", i+1, w.Code)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/nitesh/news_service/pkg/models"
+)
+
+// CategoryNormalizer canonicalizes article categories at ingest. Categories are
+// matched case-insensitively: aliases map to their canonical name and
+// allow-listed names are lower-cased. Anything else is kept verbatim, or
+// dropped in strict mode.
+type CategoryNormalizer struct {
+	aliases map[string]string
+	allowed map[string]bool
+	strict  bool
+}
+
+// NewCategoryNormalizer builds a normalizer from an alias map (alias -> canonical)
+// and an allow-list. Alias targets are implicitly allowed.
+func NewCategoryNormalizer(aliases map[string]string, allowed []string, strict bool) *CategoryNormalizer {
+	n := &CategoryNormalizer{
+		aliases: make(map[string]string, len(aliases)),
+		allowed: make(map[string]bool, len(allowed)+len(aliases)),
+		strict:  strict,
+	}
+	for _, c := range allowed {
+		if c = normalizeCategory(c); c != "" {
+			n.allowed[c] = true
+		}
+	}
+	for alias, canonical := range aliases {
+		canonical = normalizeCategory(canonical)
+		if canonical == "" {
+			continue
+		}
+		n.aliases[normalizeCategory(alias)] = canonical
+		n.allowed[canonical] = true
+	}
+	return n
+}
+
+// Normalize returns cats canonicalized and de-duplicated, in input order.
+func (n *CategoryNormalizer) Normalize(cats []string) []string {
+	out := make([]string, 0, len(cats))
+	seen := make(map[string]bool, len(cats))
+	for _, raw := range cats {
+		key := normalizeCategory(raw)
+		if key == "" {
+			continue
+		}
+		c := strings.TrimSpace(raw)
+		if canonical, ok := n.aliases[key]; ok {
+			c = canonical
+		} else if n.allowed[key] {
+			c = key
+		} else if n.strict {
+			continue
+		}
+		if seen[strings.ToLower(c)] {
+			continue
+		}
+		seen[strings.ToLower(c)] = true
+		out = append(out, c)
+	}
+	return out
+}
+
+// normalizeCategory lower-cases c and collapses whitespace.
+func normalizeCategory(c string) string {
+	return strings.ToLower(strings.Join(strings.Fields(c), " "))
+}
+
+// ParseCategoryAliases parses a JSON object of alias -> canonical category,
+// e.g. {"tech": "technology", "Sci": "science"}. An empty string yields no aliases.
+func ParseCategoryAliases(s string) (map[string]string, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	var aliases map[string]string
+	if err := json.Unmarshal([]byte(s), &aliases); err != nil {
+		return nil, fmt.Errorf("category aliases: %w", err)
+	}
+	return aliases, nil
+}
+
+// normalizeCategories applies the configured normalizer, if any, to every article.
+func (s *Service) normalizeCategories(articles ...*models.Article) {
+	if s.categories == nil {
+		return
+	}
+	for _, a := range articles {
+		a.Categories = s.categories.Normalize(a.Categories)
+	}
+}
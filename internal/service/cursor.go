@@ -0,0 +1,52 @@
+package service
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nitesh/news_service/pkg/models"
+)
+
+// ErrInvalidCursor is returned when a client supplied cursor cannot be decoded.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// encodeCursor builds an opaque keyset cursor from the last article of a page.
+// Format (before base64): <search_rank>|<relevance_score>|<published_at RFC3339Nano>|<id>
+func encodeCursor(a *models.Article) string {
+	raw := strconv.FormatFloat(a.SearchRank, 'g', -1, 64) + "|" +
+		strconv.FormatFloat(a.Relevance, 'g', -1, 64) + "|" +
+		a.PublishedAt.UTC().Format(time.RFC3339Nano) + "|" + a.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeCursor parses a cursor produced by encodeCursor.
+// An empty string means "start from the beginning" and returns nil.
+func decodeCursor(s string) (*models.Cursor, error) {
+	if s == "" {
+		return nil, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	parts := strings.SplitN(string(b), "|", 4)
+	if len(parts) != 4 || parts[3] == "" {
+		return nil, ErrInvalidCursor
+	}
+	rank, err := strconv.ParseFloat(parts[0], 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	score, err := strconv.ParseFloat(parts[1], 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	ts, err := time.Parse(time.RFC3339Nano, parts[2])
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &models.Cursor{Rank: rank, Relevance: score, PublishedAt: ts, ID: parts[3]}, nil
+}
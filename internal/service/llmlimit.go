@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrLLMBusy is returned when an LLM call can't get an in-flight slot in time
+// (see SetLLMMaxInflight).
+var ErrLLMBusy = errors.New("llm busy: too many requests in flight")
+
+// defaultLLMInflightWait is how long a call waits for a free LLM slot.
+const defaultLLMInflightWait = 30 * time.Second
+
+// SetLLMMaxInflight bounds the LLM calls in flight across all requests, batch
+// workers and summary jobs to n; n < 1 removes the bound. A call waits at most
+// wait (or until its context ends) for a slot before failing with ErrLLMBusy;
+// a non-positive wait uses the default. Call before serving requests.
+func (s *Service) SetLLMMaxInflight(n int, wait time.Duration) {
+	if n < 1 {
+		s.llmSlots = nil
+		return
+	}
+	s.llmSlots = make(chan struct{}, n)
+	if wait <= 0 {
+		wait = defaultLLMInflightWait
+	}
+	s.llmWait = wait
+}
+
+// acquireLLM takes an in-flight LLM slot and returns the func that releases it.
+// Without a configured bound it returns immediately.
+func (s *Service) acquireLLM(ctx context.Context) (release func(), err error) {
+	if s.llmSlots == nil {
+		return func() {}, nil
+	}
+	select {
+	case s.llmSlots <- struct{}{}:
+		return func() { <-s.llmSlots }, nil
+	default:
+	}
+	timer := time.NewTimer(s.llmWait)
+	defer timer.Stop()
+	select {
+	case s.llmSlots <- struct{}{}:
+		return func() { <-s.llmSlots }, nil
+	case <-timer.C:
+		return nil, fmt.Errorf("%w: no slot within %s", ErrLLMBusy, s.llmWait)
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%w: %v", ErrLLMBusy, ctx.Err())
+	}
+}
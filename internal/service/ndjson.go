@@ -0,0 +1,103 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/nitesh/news_service/pkg/models"
+)
+
+// ndjsonBatchSize is how many parsed lines IngestNDJSON saves per Ingest call
+// (fewer when MAX_INGEST_BATCH is lower).
+const ndjsonBatchSize = 500
+
+// maxNDJSONLine bounds one NDJSON line (one article).
+const maxNDJSONLine = 1 << 20
+
+// maxReportedLineErrors caps how many failed lines NDJSONReport lists.
+const maxReportedLineErrors = 100
+
+// LineError describes why one NDJSON line was skipped.
+type LineError struct {
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
+
+// NDJSONReport summarizes an NDJSON ingest. Parsed counts valid articles,
+// Failed counts lines that didn't decode or validate (the first few are in Errors),
+// Imported counts articles saved before any store error.
+type NDJSONReport struct {
+	Parsed   int         `json:"parsed"`
+	Failed   int         `json:"failed"`
+	Imported int         `json:"imported"`
+	Errors   []LineError `json:"errors,omitempty"`
+}
+
+// IngestNDJSON reads one article per line from r and ingests them in batches,
+// so the body is never held in memory as a whole. Blank lines are ignored;
+// lines that aren't valid articles are skipped and reported. A store error
+// stops the ingest and is returned with the report so far (earlier batches stay saved).
+func (s *Service) IngestNDJSON(ctx context.Context, r io.Reader, opts IngestOptions) (*NDJSONReport, error) {
+	rep := &NDJSONReport{}
+	fail := func(line int, msg string) {
+		rep.Failed++
+		if len(rep.Errors) < maxReportedLineErrors {
+			rep.Errors = append(rep.Errors, LineError{Line: line, Message: msg})
+		}
+	}
+	size := ndjsonBatchSize
+	if s.maxIngest > 0 && s.maxIngest < size {
+		size = s.maxIngest
+	}
+	batch := make([]*models.Article, 0, size)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if _, err := s.Ingest(ctx, batch, opts); err != nil {
+			return err
+		}
+		rep.Imported += len(batch)
+		batch = make([]*models.Article, 0, size)
+		return nil
+	}
+
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), maxNDJSONLine)
+	line := 0
+	for sc.Scan() {
+		line++
+		text := strings.TrimSpace(sc.Text())
+		if text == "" {
+			continue
+		}
+		var a models.Article
+		if err := json.Unmarshal([]byte(text), &a); err != nil {
+			fail(line, "invalid json: "+err.Error())
+			continue
+		}
+		if errs := validateArticles([]*models.Article{&a}); len(errs) > 0 {
+			fail(line, errs[0].Field+" "+errs[0].Message)
+			continue
+		}
+		rep.Parsed++
+		batch = append(batch, &a)
+		if len(batch) == size {
+			if err := flush(); err != nil {
+				return rep, err
+			}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		// a line over maxNDJSONLine or a broken body: keep what was read so far
+		if ferr := flush(); ferr != nil {
+			return rep, ferr
+		}
+		return rep, fmt.Errorf("read ndjson line %d: %w", line+1, err)
+	}
+	return rep, flush()
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/nitesh/news_service/pkg/models"
+)
+
+// RelevanceScorer computes a server-side relevance score for an article at ingest.
+// The score becomes the article's base relevance (see RecomputeRelevance).
+type RelevanceScorer interface {
+	Score(a *models.Article, now time.Time) float64
+}
+
+// defaultRecencyWindow is the age at which DefaultScorer's recency signal reaches zero.
+const defaultRecencyWindow = 72 * time.Hour
+
+// Weights of DefaultScorer's signals; they sum to 1 before the source weight applies.
+const (
+	recencyWeight  = 0.5
+	categoryWeight = 0.25
+	titleWeight    = 0.25
+)
+
+// Title lengths (in runes) DefaultScorer treats as fully informative.
+const (
+	minGoodTitle = 30
+	maxGoodTitle = 120
+)
+
+// DefaultScorer scores articles in [0, 1] from recency, whether they carry
+// categories and how informative the title length is, then multiplies by the
+// source's weight.
+type DefaultScorer struct {
+	// SourceWeights maps a lower-cased source to its multiplier; unknown sources weigh 1.
+	SourceWeights map[string]float64
+	// RecencyWindow is the age at which recency stops contributing (default 72h).
+	RecencyWindow time.Duration
+}
+
+// Score implements RelevanceScorer.
+func (d DefaultScorer) Score(a *models.Article, now time.Time) float64 {
+	window := d.RecencyWindow
+	if window <= 0 {
+		window = defaultRecencyWindow
+	}
+	recency := 1.0
+	if age := now.Sub(a.PublishedAt); age > 0 {
+		recency = math.Max(0, 1-float64(age)/float64(window))
+	}
+
+	categories := 0.0
+	if len(a.Categories) > 0 {
+		categories = 1
+	}
+
+	title := 0.0
+	switch n := len([]rune(strings.TrimSpace(a.Title))); {
+	case n == 0:
+	case n < minGoodTitle:
+		title = float64(n) / minGoodTitle
+	case n <= maxGoodTitle:
+		title = 1
+	default:
+		title = math.Max(0.5, float64(maxGoodTitle)/float64(n))
+	}
+
+	score := recencyWeight*recency + categoryWeight*categories + titleWeight*title
+	if w, ok := d.SourceWeights[strings.ToLower(a.Source)]; ok {
+		score *= w
+	}
+	return math.Round(score*1000) / 1000
+}
+
+// ParseSourceWeights parses a JSON object of source -> weight (e.g.
+// {"Reuters": 1.2, "blog": 0.5}) into the lower-cased map DefaultScorer expects.
+// An empty string yields no weights.
+func ParseSourceWeights(s string) (map[string]float64, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	var raw map[string]float64
+	if err := json.Unmarshal([]byte(s), &raw); err != nil {
+		return nil, fmt.Errorf("source weights: %w", err)
+	}
+	weights := make(map[string]float64, len(raw))
+	for src, w := range raw {
+		if w < 0 {
+			return nil, fmt.Errorf("source weights: negative weight %g for %q", w, src)
+		}
+		weights[strings.ToLower(strings.TrimSpace(src))] = w
+	}
+	return weights, nil
+}
+
+// scoreArticles sets relevance with the configured scorer on articles that
+// arrived without one, or on all of them when rescore is set.
+func (s *Service) scoreArticles(articles []*models.Article, rescore bool) {
+	if s.scorer == nil {
+		return
+	}
+	now := time.Now()
+	for _, a := range articles {
+		if a.Relevance != 0 && !rescore {
+			continue
+		}
+		a.Relevance = s.scorer.Score(a, now)
+	}
+}
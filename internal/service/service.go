@@ -2,141 +2,1587 @@ package service
 
 import (
 	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/google/uuid"
+	"github.com/nitesh/news_service/internal/feed"
+	"github.com/nitesh/news_service/internal/geo"
+	"github.com/nitesh/news_service/internal/lang"
 	"github.com/nitesh/news_service/internal/llm"
 	"github.com/nitesh/news_service/pkg/models"
 	"github.com/redis/go-redis/v9"
 )
 
+// ErrNotFound is returned when the requested article does not exist.
+var ErrNotFound = errors.New("article not found")
+
+// ErrInvalidID is returned when an article id is not a UUID.
+var ErrInvalidID = errors.New("invalid article id")
+
+// ErrEmbeddingsDisabled is returned by SemanticSearch when embeddings are not enabled.
+var ErrEmbeddingsDisabled = errors.New("semantic search is disabled (set EMBEDDINGS_ENABLED=true)")
+
+// ErrIngestTooLarge is returned when an ingest batch exceeds the configured maximum.
+var ErrIngestTooLarge = errors.New("ingest batch too large")
+
+// ErrInvalidBulkDelete is returned when a bulk delete request is empty, too large
+// or contains an id that isn't a UUID.
+var ErrInvalidBulkDelete = errors.New("invalid bulk delete")
+
+// ErrModelNotAllowed is returned when a summary requests a model outside LLM_ALLOWED_MODELS.
+var ErrModelNotAllowed = errors.New("model not allowed")
+
+// ErrInvalidLength is returned when a summary requests a length other than short, medium or long.
+var ErrInvalidLength = errors.New("length must be short, medium or long")
+
+// ErrFeedUnavailable is returned when a feed can't be downloaded or parsed.
+var ErrFeedUnavailable = errors.New("feed unavailable")
+
+// ErrFeedForbidden is returned when a feed URL isn't http(s) or points at a
+// non-public address (see feed.NewClient).
+var ErrFeedForbidden = errors.New("feed url not allowed")
+
 type ArticleStore interface {
-	SaveMany([]*models.Article) error
-	Search(q string, limit int) ([]*models.Article, error)
-	FindByCategory(category string, limit int) ([]*models.Article, error)
-	All(limit int) ([]*models.Article, error)
-	GetByIDs([]string) ([]*models.Article, error)
+	SaveMany(ctx context.Context, articles []*models.Article) error
+	Search(ctx context.Context, q string, f models.ArticleFilter, sort models.SortOrder, weights map[string]float64, limit, offset int, after *models.Cursor) ([]*models.Article, bool, error)
+	FindByCategories(ctx context.Context, categories []string, matchAll bool, f models.ArticleFilter, sort models.SortOrder, limit, offset int) ([]*models.Article, error)
+	CountSearch(ctx context.Context, q string, f models.ArticleFilter) (int, error)
+	FuzzySearch(ctx context.Context, q string, threshold float64, f models.ArticleFilter, sort models.SortOrder, weights map[string]float64, limit, offset int, after *models.Cursor) ([]*models.Article, bool, error)
+	CountFuzzySearch(ctx context.Context, q string, threshold float64, f models.ArticleFilter) (int, error)
+	CountByCategories(ctx context.Context, categories []string, matchAll bool, f models.ArticleFilter) (int, error)
+	All(ctx context.Context, f models.ArticleFilter, sort models.SortOrder, limit int) ([]*models.Article, error)
+	TrendingWindow(ctx context.Context, windowHours int, f models.ArticleFilter, limit int) ([]*models.Article, error)
+	GetByIDs(ctx context.Context, ids []string) ([]*models.Article, error)
 
-	UpdateLLMSummary(id string, summary string) error
-	Nearby(lat, lon, radiusKm float64, limit int) ([]*models.Article, error)
+	UpdateLLMSummary(ctx context.Context, id, summary, model string) error
+	Nearby(ctx context.Context, lat, lon, radiusKm float64, limit, offset int, fields []string) ([]*models.Article, error)
+	NearbyPostGIS(ctx context.Context, lat, lon, radiusKm float64, limit, offset int, fields []string) ([]*models.Article, error)
+	InBoundingBox(ctx context.Context, minLat, minLon, maxLat, maxLon float64, limit int, fields []string) ([]*models.Article, error)
+	ArchiveRange(ctx context.Context, from, to time.Time, limit, offset int) ([]*models.Article, error)
+	RecentWithin(ctx context.Context, d time.Duration, limit int) ([]*models.Article, error)
+	ExportPage(ctx context.Context, from, to time.Time, after string, limit int) ([]*models.Article, error)
+	ReindexBatch(ctx context.Context, after string, limit int) ([]*models.Article, error)
+	SimilarByCategories(ctx context.Context, id string, limit int) ([]*models.Article, error)
+	Delete(ctx context.Context, id string) error
+	DeleteMany(ctx context.Context, ids []string) (int64, error)
+	Restore(ctx context.Context, id string) error
+	Deleted(ctx context.Context, limit, offset int) ([]*models.Article, error)
+	ArticlesWithoutSummary(ctx context.Context, after string, limit int) ([]*models.Article, error)
+	WithoutGeo(ctx context.Context, after string, limit int) ([]*models.Article, error)
+	UpdateGeo(ctx context.Context, id string, lat, lon float64) (*models.Article, error)
+	Update(ctx context.Context, a *models.Article) (*models.Article, error)
+	FindByURLs(ctx context.Context, urls []string) (map[string]string, error)
+	Sources(ctx context.Context, limit int) ([]models.SourceCount, error)
+	DistinctCategories(ctx context.Context, limit int) ([]models.CategoryCount, error)
+	FindDuplicateURLs(ctx context.Context, limit int) ([]models.DuplicateURL, error)
+	Stats(ctx context.Context) (*models.Stats, error)
+	SchemaStatus(ctx context.Context) (*models.SchemaStatus, error)
+	Migrate(ctx context.Context) ([]models.Migration, error)
+	RelevanceBases(ctx context.Context) ([]models.RelevanceBase, error)
+	UpdateEmbedding(ctx context.Context, id string, vec []float32) error
+	SemanticSearch(ctx context.Context, vec []float32, limit int) ([]*models.Article, error)
+	UpdateRelevanceScores(ctx context.Context, scores map[string]float64) (int64, error)
+	DB() *sql.DB
 }
 
 type Service struct {
 	repo      ArticleStore
 	rdb       *redis.Client
 	llmClient *llm.Client
+
+	trendingTTL    time.Duration
+	searchTTL      time.Duration
+	fuzzyThreshold float64
+	searchWeights  map[string]float64
+	summaryTTL     time.Duration
+	llmConcurrency int
+	llmSlots       chan struct{}
+	llmWait        time.Duration
+	halfLife       time.Duration
+	feedClient     *http.Client
+	feedTimeout    time.Duration
+	embeddings     bool
+	postgis        bool
+	maxIngest      int
+	maxBulkDelete  int
+	maxIngestSums  int
+	scorer         RelevanceScorer
+	categories     *CategoryNormalizer
+	notifier       IngestNotifier
 }
 
+// IngestNotifier is told the ids of every successful ingest (see webhook.Notifier).
+// NotifyIngest must not block.
+type IngestNotifier interface {
+	NotifyIngest(ids []string)
+}
+
+// defaultFeedFetchTimeout bounds how long downloading a feed may take unless
+// overridden via SetFeedFetchTimeout (FEED_FETCH_TIMEOUT).
+const defaultFeedFetchTimeout = 15 * time.Second
+
+// defaultRelevanceHalfLife is the age at which a recomputed relevance halves.
+const defaultRelevanceHalfLife = 48 * time.Hour
+
+// defaultSummaryTTL is how long LLM summaries are cached by content hash.
+const defaultSummaryTTL = 24 * time.Hour
+
+// summaryKeyPrefix namespaces cached summaries (summary:<sha256 of title+content>).
+const summaryKeyPrefix = "summary:"
+
+// defaultLLMConcurrency bounds parallel LLM calls in batch summarization.
+const defaultLLMConcurrency = 4
+
+// defaultMaxIngestSummaries caps how many articles of one ingest are summarized.
+const defaultMaxIngestSummaries = 100
+
+// defaultMaxBulkDelete caps how many ids one DeleteArticles call accepts.
+const defaultMaxBulkDelete = 1000
+
+// defaultTrendingTTL is used when no TTL is configured via SetTrendingCacheTTL.
+const defaultTrendingTTL = 60 * time.Second
+
+// trendingKeyPrefix namespaces cached trending results (trending:<sort>:<window>:<sources>:<lang>:<min_relevance>:<limit>:<fields>).
+const trendingKeyPrefix = "trending:"
+
+// defaultSearchTTL is used when no TTL is configured via SetSearchCacheTTL.
+const defaultSearchTTL = 30 * time.Second
+
+// defaultFuzzyThreshold is the minimum title similarity for fuzzy search (pg_trgm's own default).
+const defaultFuzzyThreshold = 0.3
+
+// searchKeyPrefix namespaces cached search pages
+// (search:v<version>:<q>:<sort>:<sources>:<lang>:<limit>:<cursor>:<fields>).
+const searchKeyPrefix = "search:"
+
+// searchVersionKey holds the search cache generation; bumping it on writes
+// orphans every cached page, which then expire via their TTL.
+const searchVersionKey = "search:version"
+
 // func NewService(repo ArticleStore, rdb *redis.Client) *Service {
 //     return &Service{repo: repo, rdb: rdb}
 // }
 
 func NewService(repo ArticleStore, rdb *redis.Client, llmClient *llm.Client) *Service {
-	return &Service{repo: repo, rdb: rdb, llmClient: llmClient}
+	return &Service{
+		repo:           repo,
+		rdb:            rdb,
+		llmClient:      llmClient,
+		trendingTTL:    defaultTrendingTTL,
+		searchTTL:      defaultSearchTTL,
+		fuzzyThreshold: defaultFuzzyThreshold,
+		summaryTTL:     defaultSummaryTTL,
+		llmConcurrency: defaultLLMConcurrency,
+		halfLife:       defaultRelevanceHalfLife,
+		feedClient:     feed.NewClient(),
+		feedTimeout:    defaultFeedFetchTimeout,
+		maxBulkDelete:  defaultMaxBulkDelete,
+		maxIngestSums:  defaultMaxIngestSummaries,
+	}
+}
+
+// SetSummaryCacheTTL sets how long generated summaries are cached in Redis.
+// A non-positive ttl disables the summary cache.
+func (s *Service) SetSummaryCacheTTL(ttl time.Duration) {
+	s.summaryTTL = ttl
+}
+
+// SetRelevanceHalfLife sets the half-life used by RecomputeRelevance.
+// Non-positive values are ignored.
+func (s *Service) SetRelevanceHalfLife(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	s.halfLife = d
+}
+
+// SetFeedFetchTimeout bounds how long IngestFeed may spend downloading a feed,
+// on top of the caller's context. Non-positive values are ignored.
+func (s *Service) SetFeedFetchTimeout(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	s.feedTimeout = d
+}
+
+// SetEmbeddingsEnabled turns on embedding computation at ingest and semantic search.
+// The store must have the pgvector column (see store.RunVectorMigrations).
+func (s *Service) SetEmbeddingsEnabled(enabled bool) {
+	s.embeddings = enabled
+}
+
+// SetPostGISEnabled switches Nearby from the Haversine query to the PostGIS one.
+// The store must have the geography column (see store.RunPostGISMigrations).
+func (s *Service) SetPostGISEnabled(enabled bool) {
+	s.postgis = enabled
+}
+
+// SetMaxIngestBatch caps how many articles one Ingest call accepts.
+// A non-positive value removes the cap.
+func (s *Service) SetMaxIngestBatch(n int) {
+	s.maxIngest = n
+}
+
+// SetMaxBulkDelete caps how many ids one DeleteArticles call accepts.
+// Values below 1 are ignored.
+func (s *Service) SetMaxBulkDelete(n int) {
+	if n < 1 {
+		return
+	}
+	s.maxBulkDelete = n
+}
+
+// SetMaxIngestSummaries caps how many articles SummarizeIngested handles per
+// call; the rest are skipped. Values below 1 are ignored.
+func (s *Service) SetMaxIngestSummaries(n int) {
+	if n < 1 {
+		return
+	}
+	s.maxIngestSums = n
+}
+
+// SetRelevanceScorer enables server-side relevance scoring at ingest.
+// A nil scorer (the default) keeps client-supplied relevance as is.
+func (s *Service) SetRelevanceScorer(r RelevanceScorer) {
+	s.scorer = r
+}
+
+// SetIngestNotifier installs a notifier called after each successful Ingest.
+// A nil notifier (the default) disables notifications.
+func (s *Service) SetIngestNotifier(n IngestNotifier) {
+	s.notifier = n
+}
+
+// SetCategoryNormalizer enables category normalization on ingest and update.
+// A nil normalizer (the default) stores categories verbatim.
+func (s *Service) SetCategoryNormalizer(n *CategoryNormalizer) {
+	s.categories = n
+}
+
+// SetLLMConcurrency sets the worker pool size used by SummarizeBatch.
+// Values below 1 are ignored.
+func (s *Service) SetLLMConcurrency(n int) {
+	if n < 1 {
+		return
+	}
+	s.llmConcurrency = n
+}
+
+// DB returns the underlying database handle used by the store.
+func (s *Service) DB() *sql.DB {
+	return s.repo.DB()
+}
+
+// Redis returns the Redis client (may be nil).
+func (s *Service) Redis() *redis.Client {
+	return s.rdb
+}
+
+// SetTrendingCacheTTL sets how long trending results are cached in Redis.
+// A non-positive ttl disables caching.
+func (s *Service) SetTrendingCacheTTL(ttl time.Duration) {
+	s.trendingTTL = ttl
+}
+
+// SetSearchCacheTTL sets how long search results are cached in Redis.
+// A non-positive ttl disables caching.
+func (s *Service) SetSearchCacheTTL(ttl time.Duration) {
+	s.searchTTL = ttl
 }
 
-// SummarizeArticle generates a short summary for an article (2-4 sentences),
-// saves it into the DB and returns the summary.
-func (s *Service) SummarizeArticle(ctx context.Context, id string) (string, error) {
+// SetFuzzyThreshold sets the minimum trigram similarity (0-1) of a title to the
+// query for fuzzy search. Values outside (0, 1] are ignored.
+func (s *Service) SetFuzzyThreshold(t float64) {
+	if t <= 0 || t > 1 {
+		return
+	}
+	s.fuzzyThreshold = t
+}
+
+// SetSearchSourceWeights sets per-source multipliers of the search rank, keyed by
+// lower-cased source (see ParseSourceWeights), so a low-quality source ranks lower
+// at equal text relevance. Unlisted sources weigh 1; nil removes all weighting.
+func (s *Service) SetSearchSourceWeights(weights map[string]float64) {
+	s.searchWeights = weights
+}
+
+// SearchSourceWeights returns a copy of the effective search source weights.
+func (s *Service) SearchSourceWeights() map[string]float64 {
+	out := make(map[string]float64, len(s.searchWeights))
+	for src, w := range s.searchWeights {
+		out[src] = w
+	}
+	return out
+}
+
+// searchWeightsTag fingerprints the search source weights for cache keys, so
+// instances configured differently never share cached pages.
+func (s *Service) searchWeightsTag() string {
+	if len(s.searchWeights) == 0 {
+		return "w0"
+	}
+	srcs := make([]string, 0, len(s.searchWeights))
+	for src := range s.searchWeights {
+		srcs = append(srcs, src)
+	}
+	sort.Strings(srcs)
+	h := sha256.New()
+	for _, src := range srcs {
+		fmt.Fprintf(h, "%s=%g;", src, s.searchWeights[src])
+	}
+	return "w" + hex.EncodeToString(h.Sum(nil))[:12]
+}
+
+// SummaryOptions tweaks how a single article is summarized.
+type SummaryOptions struct {
+	// Model selects the LLM model; empty uses the default, others must be
+	// allowed (ErrModelNotAllowed).
+	Model string
+	// Length is llm.LengthShort, LengthMedium or LengthLong (ErrInvalidLength
+	// otherwise); empty means medium.
+	Length string
+	// Force regenerates the summary even if the article already has one.
+	Force bool
+}
+
+// SummarizeArticle generates a short summary for an article (2-3 sentences by
+// default, see SummaryOptions.Length), saves it into the DB and returns the summary.
+// An article that already has a summary gets it back without calling the LLM
+// unless opts.Force is set. cached reports that no fresh generation happened
+// (the stored summary or a Redis-cached one was used). model is the LLM model
+// that produced the summary (the fallback model if the requested one failed);
+// it is empty for a stored summary of unknown provenance.
+func (s *Service) SummarizeArticle(ctx context.Context, id string, opts SummaryOptions) (summary string, cached bool, model string, err error) {
+	if err := checkID(id); err != nil {
+		return "", false, "", err
+	}
+	opts, err = s.checkSummaryOptions(opts)
+	if err != nil {
+		return "", false, "", err
+	}
 	// fetch article
-	arts, err := s.repo.GetByIDs([]string{id})
+	arts, err := s.repo.GetByIDs(ctx, []string{id})
 	if err != nil {
-		return "", fmt.Errorf("fetch article: %w", err)
+		return "", false, "", fmt.Errorf("fetch article: %w", err)
 	}
 	if len(arts) == 0 {
-		return "", fmt.Errorf("article not found")
+		return "", false, "", ErrNotFound
+	}
+	if !opts.Force && arts[0].LLMSummary != "" {
+		return arts[0].LLMSummary, true, arts[0].SummaryModel, nil
 	}
-	art := arts[0]
+	return s.summarizeAndSave(ctx, arts[0], opts)
+}
 
-	// pick best text to summarize (use Description if present, otherwise Title)
-	content := art.Description
-	if content == "" {
-		content = art.Title
+// checkModel validates a requested model, normalizing the default model to "".
+func (s *Service) checkModel(model string) (string, error) {
+	if !s.llmClient.ModelAllowed(model) {
+		return "", fmt.Errorf("%w: %q", ErrModelNotAllowed, model)
 	}
-	// optional: truncate content if extremely large to keep token cost reasonable
-	// but the LLM client will still accept it. We'll limit to first 30k chars
-	if len(content) > 30000 {
-		content = content[:30000]
+	if model == s.llmClient.Model() {
+		return "", nil
 	}
+	return model, nil
+}
 
-	// call the llm client
-	summary, err := s.llmClient.SummarizeArticleText(ctx, art.Title, content)
+// checkSummaryOptions validates opts, normalizing the default model and length to "".
+func (s *Service) checkSummaryOptions(opts SummaryOptions) (SummaryOptions, error) {
+	model, err := s.checkModel(opts.Model)
+	if err != nil {
+		return opts, err
+	}
+	opts.Model = model
+	if !llm.ValidLength(opts.Length) {
+		return opts, fmt.Errorf("%w: %q", ErrInvalidLength, opts.Length)
+	}
+	if opts.Length == llm.LengthMedium {
+		opts.Length = ""
+	}
+	return opts, nil
+}
+
+// DebugSummary runs the summarization for article id like SummarizeArticle
+// (opts.Force is implied) but neither caches nor saves the result; it returns
+// the raw LLM exchange instead. On an LLM failure the partial result is
+// returned with the error.
+func (s *Service) DebugSummary(ctx context.Context, id string, opts SummaryOptions) (*llm.RawResult, error) {
+	opts, err := s.checkSummaryOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	art, err := s.GetArticle(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	release, err := s.acquireLLM(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	res, err := s.llmClient.SummarizeRaw(ctx, art.Title, summaryContent(art), llm.GenerateOptions{Model: opts.Model, Length: opts.Length})
 	if err != nil {
-		return "", fmt.Errorf("llm summarize: %w", err)
+		return res, fmt.Errorf("llm summarize: %w", err)
+	}
+	return res, nil
+}
+
+// summaryContent picks the text to summarize: the full content when stored,
+// else the description, else the title.
+func summaryContent(art *models.Article) string {
+	if art.Content != "" {
+		return art.Content
+	}
+	if art.Description == "" {
+		return art.Title
 	}
-	art.LLMSummary = summary
-	if err := s.repo.SaveMany([]*models.Article{art}); err != nil {
-		return "", fmt.Errorf("save summary: %w", err)
+	return art.Description
+}
+
+// summarizeAndSave calls the LLM for a single article and persists the summary
+// with the model that generated it. opts must have been checked (see
+// checkSummaryOptions); Force is ignored. cached reports that the summary came
+// from the Redis cache instead of the LLM.
+func (s *Service) summarizeAndSave(ctx context.Context, art *models.Article, opts SummaryOptions) (string, bool, string, error) {
+	content := summaryContent(art)
+	// over-long content is truncated by the LLM client to its input token budget
+
+	requested := opts.Model
+	if requested == "" {
+		requested = s.llmClient.Model()
+	}
+	// identical title+content produces the same summary, so reuse a cached one;
+	// only the requested model's output is cached, so a hit came from it
+	key := summaryCacheKey(opts.Model, opts.Length, art.Title, content)
+	summary, cached := s.cachedSummary(ctx, key)
+	model := requested
+	if !cached {
+		// call the llm client
+		release, err := s.acquireLLM(ctx)
+		if err != nil {
+			return "", false, "", err
+		}
+		summary, model, err = s.llmClient.SummarizeArticleText(ctx, art.Title, content, llm.GenerateOptions{Model: opts.Model, Length: opts.Length})
+		release()
+		if err != nil {
+			return "", false, "", fmt.Errorf("llm summarize: %w", err)
+		}
+		// a fallback model's summary isn't cached: the next request should
+		// try the requested model again
+		if model == requested {
+			s.cacheSummary(ctx, key, summary)
+		}
 	}
+	art.LLMSummary, art.SummaryModel = summary, model
 
 	// persist summary
-	if err := s.repo.UpdateLLMSummary(art.ID, summary); err != nil {
-		return "", fmt.Errorf("save summary: %w", err)
+	if err := s.repo.UpdateLLMSummary(ctx, art.ID, summary, model); err != nil {
+		return "", false, "", fmt.Errorf("save summary: %w", err)
+	}
+
+	return summary, cached, model, nil
+}
+
+// summaryCacheKey derives the Redis key for a summary from the text sent to the LLM
+// and the model and length overrides (empty for the defaults).
+func summaryCacheKey(model, length, title, content string) string {
+	text := title + "\n" + content
+	if model != "" {
+		text += "\n" + model
+	}
+	if length != "" {
+		text += "\nlength:" + length
+	}
+	sum := sha256.Sum256([]byte(text))
+	return summaryKeyPrefix + hex.EncodeToString(sum[:])
+}
+
+// cachedSummary looks up a summary in Redis; any Redis error is treated as a miss.
+func (s *Service) cachedSummary(ctx context.Context, key string) (string, bool) {
+	if s.rdb == nil || s.summaryTTL <= 0 {
+		return "", false
+	}
+	v, err := s.rdb.Get(ctx, key).Result()
+	if err != nil {
+		if err != redis.Nil {
+			log.Printf("warning: summary cache get: %v", err)
+		}
+		return "", false
+	}
+	return v, true
+}
+
+// cacheSummary stores a summary in Redis. Errors are only logged.
+func (s *Service) cacheSummary(ctx context.Context, key, summary string) {
+	if s.rdb == nil || s.summaryTTL <= 0 {
+		return
+	}
+	if err := s.rdb.SetEx(ctx, key, summary, s.summaryTTL).Err(); err != nil {
+		log.Printf("warning: summary cache set: %v", err)
+	}
+}
+
+// SummarizeBatch summarizes many articles concurrently using a bounded worker pool.
+// It returns the summaries keyed by id and the ids that failed (including ids not found
+// or not UUIDs); individual failures never abort the batch.
+func (s *Service) SummarizeBatch(ctx context.Context, ids []string) (map[string]string, []string, error) {
+	failed := []string{}
+	valid := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if checkID(id) != nil {
+			failed = append(failed, id)
+			continue
+		}
+		valid = append(valid, id)
+	}
+	arts, err := s.repo.GetByIDs(ctx, valid)
+	if err != nil {
+		return nil, nil, fmt.Errorf("fetch articles: %w", err)
+	}
+
+	summaries := map[string]string{}
+	found := map[string]bool{}
+	for _, a := range arts {
+		found[a.ID] = true
+	}
+	for _, id := range valid {
+		if !found[id] {
+			failed = append(failed, id)
+		}
+	}
+
+	var mu sync.Mutex
+	s.forEachBounded(arts, func(art *models.Article) {
+		summary, _, _, err := s.summarizeAndSave(ctx, art, SummaryOptions{})
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			log.Printf("batch summary id=%s: %v", art.ID, err)
+			failed = append(failed, art.ID)
+			return
+		}
+		summaries[art.ID] = summary
+	})
+
+	return summaries, failed, nil
+}
+
+// IngestSummaryReport tells how summarize-on-ingest went. Skipped counts
+// articles over the per-ingest cap (see SetMaxIngestSummaries).
+type IngestSummaryReport struct {
+	Requested int  `json:"requested"`
+	Succeeded int  `json:"succeeded"`
+	Failed    int  `json:"failed"`
+	Skipped   int  `json:"skipped"`
+	Queued    int  `json:"queued,omitempty"`
+	Async     bool `json:"async"`
+}
+
+// SummarizeIngested summarizes freshly ingested articles. Synchronously it runs
+// them through the bounded LLM worker pool and waits; with async it enqueues a
+// summary job per article instead (ErrQueueUnavailable without Redis).
+// At most the configured maximum of distinct ids is processed.
+func (s *Service) SummarizeIngested(ctx context.Context, ids []string, async bool) (*IngestSummaryReport, error) {
+	if async && s.rdb == nil {
+		return nil, ErrQueueUnavailable
+	}
+	seen := make(map[string]struct{}, len(ids))
+	unique := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		unique = append(unique, id)
+	}
+	rep := &IngestSummaryReport{Requested: len(unique), Async: async}
+	if len(unique) > s.maxIngestSums {
+		rep.Skipped = len(unique) - s.maxIngestSums
+		unique = unique[:s.maxIngestSums]
+	}
+
+	if async {
+		for _, id := range unique {
+			// like the synchronous path, replace summaries supplied with the articles
+			if _, err := s.enqueueSummary(ctx, id, SummaryOptions{Force: true}); err != nil {
+				log.Printf("ingest summary enqueue id=%s: %v", id, err)
+				rep.Failed++
+				continue
+			}
+			rep.Queued++
+		}
+		return rep, nil
+	}
+
+	summaries, failed, err := s.SummarizeBatch(ctx, unique)
+	if err != nil {
+		return nil, err
+	}
+	rep.Succeeded = len(summaries)
+	rep.Failed = len(failed)
+	return rep, nil
+}
+
+// forEachBounded runs fn for every article using at most llmConcurrency goroutines
+// and waits for all of them to finish.
+func (s *Service) forEachBounded(arts []*models.Article, fn func(*models.Article)) {
+	var wg sync.WaitGroup
+	jobs := make(chan *models.Article)
+	workers := s.llmConcurrency
+	if workers > len(arts) {
+		workers = len(arts)
+	}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for art := range jobs {
+				fn(art)
+			}
+		}()
+	}
+	for _, a := range arts {
+		jobs <- a
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// GetArticle returns the full article record for id, ErrNotFound, or
+// ErrInvalidID when id isn't a UUID.
+func (s *Service) GetArticle(ctx context.Context, id string) (*models.Article, error) {
+	if err := checkID(id); err != nil {
+		return nil, err
+	}
+	arts, err := s.repo.GetByIDs(ctx, []string{id})
+	if err != nil {
+		return nil, fmt.Errorf("fetch article: %w", err)
 	}
+	if len(arts) == 0 {
+		return nil, ErrNotFound
+	}
+	return arts[0], nil
+}
 
-	return summary, nil
+// checkID rejects ids that would fail the uuid cast in Postgres.
+func checkID(id string) error {
+	if _, err := uuid.Parse(id); err != nil {
+		return fmt.Errorf("%w: %q", ErrInvalidID, id)
+	}
+	return nil
+}
+
+// DeleteArticle soft-deletes an article by id, returning ErrNotFound if it doesn't exist.
+func (s *Service) DeleteArticle(ctx context.Context, id string) error {
+	if err := s.repo.Delete(ctx, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("delete article: %w", err)
+	}
+	// the deleted article may be part of a cached trending page
+	s.invalidateCaches(ctx)
+	return nil
 }
 
-// Ingest articles
-func (s *Service) Ingest(ctx context.Context, articles []*models.Article) error {
+// DeleteArticles soft-deletes many articles and returns how many were deleted.
+// Duplicate ids are ignored; ids that don't exist (or are already deleted) don't count.
+// It returns ErrInvalidBulkDelete for an empty or oversized list or a non-UUID id.
+func (s *Service) DeleteArticles(ctx context.Context, ids []string) (int64, error) {
+	if len(ids) == 0 {
+		return 0, fmt.Errorf("%w: ids must not be empty", ErrInvalidBulkDelete)
+	}
+	if len(ids) > s.maxBulkDelete {
+		return 0, fmt.Errorf("%w: %d ids (max %d)", ErrInvalidBulkDelete, len(ids), s.maxBulkDelete)
+	}
+	seen := make(map[string]struct{}, len(ids))
+	unique := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if _, err := uuid.Parse(id); err != nil {
+			return 0, fmt.Errorf("%w: %q is not a valid id", ErrInvalidBulkDelete, id)
+		}
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		unique = append(unique, id)
+	}
+	n, err := s.repo.DeleteMany(ctx, unique)
+	if err != nil {
+		return 0, fmt.Errorf("delete articles: %w", err)
+	}
+	if n > 0 {
+		s.invalidateCaches(ctx)
+	}
+	return n, nil
+}
+
+// RestoreArticle undoes a soft delete, returning ErrNotFound if no deleted article has that id.
+func (s *Service) RestoreArticle(ctx context.Context, id string) error {
+	if err := s.repo.Restore(ctx, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("restore article: %w", err)
+	}
+	// the restored article may belong on a cached trending page
+	s.invalidateCaches(ctx)
+	return nil
+}
+
+// DuplicateURLs reports URLs stored on more than one live article, for cleanup
+// (e.g. with BulkDelete) before URL deduplication is enforced.
+func (s *Service) DuplicateURLs(ctx context.Context, limit int) ([]models.DuplicateURL, error) {
+	return s.repo.FindDuplicateURLs(ctx, limit)
+}
+
+// DeletedArticles lists soft-deleted articles, most recently deleted first.
+func (s *Service) DeletedArticles(ctx context.Context, limit, offset int) ([]*models.Article, error) {
+	return s.repo.Deleted(ctx, limit, offset)
+}
+
+// UnsummarizedArticles pages through live articles lacking an LLM summary, by id.
+// after is the last id of the previous page ("" for the first).
+func (s *Service) UnsummarizedArticles(ctx context.Context, after string, limit int) ([]*models.Article, error) {
+	if after != "" {
+		if err := checkID(after); err != nil {
+			return nil, err
+		}
+	}
+	return s.repo.ArticlesWithoutSummary(ctx, after, limit)
+}
+
+// UngeocodedArticles pages through live articles without coordinates, by id.
+// after is the last id of the previous page ("" for the first).
+func (s *Service) UngeocodedArticles(ctx context.Context, after string, limit int) ([]*models.Article, error) {
+	if after != "" {
+		if err := checkID(after); err != nil {
+			return nil, err
+		}
+	}
+	return s.repo.WithoutGeo(ctx, after, limit)
+}
+
+// UpdateGeo writes coordinates back for an article (e.g. from a geocoding
+// worker) and returns the stored record, or ErrNotFound if it doesn't exist.
+// Callers validate the coordinate ranges.
+func (s *Service) UpdateGeo(ctx context.Context, id string, lat, lon float64) (*models.Article, error) {
+	updated, err := s.repo.UpdateGeo(ctx, id, lat, lon)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("update geo: %w", err)
+	}
+	// cached pages embed the old coordinates
+	s.invalidateCaches(ctx)
+	return updated, nil
+}
+
+// UpdateArticle replaces the mutable fields of an existing article and returns
+// the stored record, or ErrNotFound if it doesn't exist.
+func (s *Service) UpdateArticle(ctx context.Context, a *models.Article) (*models.Article, error) {
+	if a.PublishedAt.IsZero() {
+		a.PublishedAt = time.Now()
+	}
+	setLanguage(a)
+	s.normalizeCategories(a)
+	updated, err := s.repo.Update(ctx, a)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("update article: %w", err)
+	}
+	// title/relevance changes can reorder a cached trending page
+	s.invalidateCaches(ctx)
+	return updated, nil
+}
+
+// IngestOptions tweaks how Ingest processes a batch.
+type IngestOptions struct {
+	// AutoCategorize asks the LLM to categorize articles that arrive without categories.
+	AutoCategorize bool
+	// Rescore replaces client-supplied relevance with the configured scorer's.
+	// Without it only articles with zero relevance are scored.
+	Rescore bool
+	// DefaultSource is stored as the source of articles posted without one.
+	// When empty, such articles get their URL's host instead (see sourceFromURL).
+	DefaultSource string
+}
+
+// Ingest articles. It returns the stored id of each article in input order:
+// server-assigned for articles posted without one, or the existing record's id
+// when the URL was already known.
+func (s *Service) Ingest(ctx context.Context, articles []*models.Article, opts IngestOptions) ([]string, error) {
+	if s.maxIngest > 0 && len(articles) > s.maxIngest {
+		return nil, fmt.Errorf("%w: %d articles (max %d)", ErrIngestTooLarge, len(articles), s.maxIngest)
+	}
+	if errs := validateArticles(articles); len(errs) > 0 {
+		return nil, &ValidationError{Errors: errs}
+	}
 	// set defaults
 	for _, a := range articles {
+		if a.ID == "" {
+			a.ID = uuid.New().String()
+		}
 		if a.PublishedAt.IsZero() {
 			a.PublishedAt = time.Now()
 		}
+		if a.Source = strings.TrimSpace(a.Source); a.Source == "" {
+			a.Source = strings.TrimSpace(opts.DefaultSource)
+			if a.Source == "" {
+				a.Source = sourceFromURL(a.URL)
+			}
+		}
+		setLanguage(a)
+	}
+	if opts.AutoCategorize {
+		s.autoCategorize(ctx, articles)
+	}
+	s.normalizeCategories(articles...)
+	// scored after categorizing, since categories are one of the signals
+	s.scoreArticles(articles, opts.Rescore)
+	if err := s.dedupeByURL(ctx, articles); err != nil {
+		return nil, err
 	}
-	return s.repo.SaveMany(articles)
+	if err := s.repo.SaveMany(ctx, articles); err != nil {
+		var partial *models.PartialSaveError
+		if errors.As(err, &partial) {
+			// the committed chunks are visible, so cached trending pages are stale
+			s.invalidateCaches(ctx)
+		}
+		return nil, err
+	}
+	if s.embeddings {
+		s.embedArticles(ctx, articles)
+	}
+	// new articles can change the trending order
+	s.invalidateCaches(ctx)
+
+	ids := make([]string, len(articles))
+	for i, a := range articles {
+		ids[i] = a.ID
+	}
+	if s.notifier != nil && len(ids) > 0 {
+		s.notifier.NotifyIngest(ids)
+	}
+	return ids, nil
 }
 
-func (s *Service) Search(ctx context.Context, q string, limit int) ([]*models.Article, error) {
-	return s.repo.Search(q, limit)
+// IngestPlan is the outcome of a dry-run ingest.
+type IngestPlan struct {
+	WouldInsert int
+	WouldUpdate int
+	Errors      []ArticleError
 }
 
-func (s *Service) Category(ctx context.Context, category string, limit int) ([]*models.Article, error) {
-	return s.repo.FindByCategory(category, limit)
+// DryRunIngest validates articles and reports how many would be inserted vs
+// updated (matched by id or URL, as Ingest would) without writing anything.
+// Invalid articles are listed in Errors and not counted.
+func (s *Service) DryRunIngest(ctx context.Context, articles []*models.Article) (*IngestPlan, error) {
+	if s.maxIngest > 0 && len(articles) > s.maxIngest {
+		return nil, fmt.Errorf("%w: %d articles (max %d)", ErrIngestTooLarge, len(articles), s.maxIngest)
+	}
+	plan := &IngestPlan{Errors: validateArticles(articles)}
+	invalid := map[int]bool{}
+	for _, e := range plan.Errors {
+		invalid[e.Index] = true
+	}
+
+	ids := []string{}
+	urls := []string{}
+	for i, a := range articles {
+		if invalid[i] {
+			continue
+		}
+		if a.ID != "" {
+			ids = append(ids, a.ID)
+		}
+		urls = append(urls, a.URL)
+	}
+	existingIDs := map[string]bool{}
+	found, err := s.repo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("lookup ids: %w", err)
+	}
+	for _, a := range found {
+		existingIDs[a.ID] = true
+	}
+	existingURLs, err := s.repo.FindByURLs(ctx, urls)
+	if err != nil {
+		return nil, fmt.Errorf("lookup urls: %w", err)
+	}
+
+	// later articles in the batch with an already-seen id/URL update the earlier one
+	for i, a := range articles {
+		if invalid[i] {
+			continue
+		}
+		_, urlKnown := existingURLs[a.URL]
+		if (a.ID != "" && existingIDs[a.ID]) || (a.URL != "" && urlKnown) {
+			plan.WouldUpdate++
+		} else {
+			plan.WouldInsert++
+		}
+		if a.ID != "" {
+			existingIDs[a.ID] = true
+		}
+		if a.URL != "" {
+			existingURLs[a.URL] = a.ID
+		}
+	}
+	return plan, nil
 }
 
-func (s *Service) Trending(ctx context.Context, limit int) ([]*models.Article, error) {
-	return s.repo.All(limit)
+// IngestFeed downloads an RSS 2.0 or Atom feed, maps its items to articles
+// (source = defaultSource, else the feed title, else the feed's host) and
+// ingests them. Returns the feed title and the number of articles imported.
+func (s *Service) IngestFeed(ctx context.Context, feedURL, defaultSource string) (string, int, error) {
+	fetchCtx, cancel := context.WithTimeout(ctx, s.feedTimeout)
+	f, err := feed.Fetch(fetchCtx, s.feedClient, feedURL)
+	cancel()
+	if errors.Is(err, feed.ErrForbiddenURL) {
+		return "", 0, fmt.Errorf("%w: %v", ErrFeedForbidden, err)
+	}
+	if err != nil {
+		return "", 0, fmt.Errorf("%w: %v", ErrFeedUnavailable, err)
+	}
+	// items carry no source of their own: use defaultSource, else the channel
+	// title, else the feed's host
+	source := strings.TrimSpace(defaultSource)
+	if source == "" {
+		source = f.Title
+	}
+	if source == "" {
+		source = sourceFromURL(feedURL)
+	}
+	for _, a := range f.Articles {
+		a.Source = source
+	}
+	// skip items that wouldn't pass ingest validation rather than failing the whole feed
+	invalid := map[int]bool{}
+	for _, e := range validateArticles(f.Articles) {
+		invalid[e.Index] = true
+	}
+	articles := make([]*models.Article, 0, len(f.Articles))
+	for i, a := range f.Articles {
+		if invalid[i] {
+			log.Printf("feed %s: skipping invalid item %d", feedURL, i)
+			continue
+		}
+		articles = append(articles, a)
+	}
+	if len(articles) == 0 {
+		return f.Title, 0, nil
+	}
+	if _, err := s.Ingest(ctx, articles, IngestOptions{}); err != nil {
+		return "", 0, err
+	}
+	return f.Title, len(articles), nil
 }
 
-// func (s *Service) Nearby(ctx context.Context, lat, lon, radiusKm float64, limit int) ([]*models.Article, error) {
-// 	all, err := s.repo.All(1000) // fetch candidates (for small dataset)
-// 	if err != nil {
-// 		return nil, err
-// 	}
-// 	out := []*models.Article{}
-// 	for _, a := range all {
-// 		if a.Latitude == 0 && a.Longitude == 0 {
-// 			continue
-// 		}
-// 		dist := haversineKm(lat, lon, a.Latitude, a.Longitude)
-// 		if dist <= radiusKm {
-// 			a.DistanceKm = dist
-// 			out = append(out, a)
-// 		}
-// 		if limit > 0 && len(out) >= limit {
-// 			break
-// 		}
-// 	}
-// 	// sort by distance
-// 	sort.Slice(out, func(i, j int) bool { return out[i].DistanceKm < out[j].DistanceKm })
-// 	return out, nil
-// }
+// sourceFromURL derives a source name from an article or feed URL: its
+// lower-cased host without port or a leading "www.", so www.example.com and
+// example.com group together. It returns "" when raw has no host.
+func sourceFromURL(raw string) string {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+}
+
+// setLanguage normalizes a client-supplied language code, or detects one from
+// title + description when none was given.
+func setLanguage(a *models.Article) {
+	if code := lang.Normalize(a.Language); code != "" {
+		a.Language = code
+		return
+	}
+	a.Language = lang.Detect(a.Title + "\n\n" + a.Description)
+}
+
+// embedArticles computes and stores embeddings for title + description and
+// returns how many were stored. Failures are logged; the article simply won't
+// appear in semantic search.
+func (s *Service) embedArticles(ctx context.Context, articles []*models.Article) int {
+	var stored atomic.Int64
+	s.forEachBounded(articles, func(a *models.Article) {
+		release, err := s.acquireLLM(ctx)
+		if err != nil {
+			log.Printf("embed id=%s: %v", a.ID, err)
+			return
+		}
+		vec, err := s.llmClient.Embed(ctx, embeddingText(a))
+		release()
+		if err != nil {
+			log.Printf("embed id=%s: %v", a.ID, err)
+			return
+		}
+		if err := s.repo.UpdateEmbedding(ctx, a.ID, vec); err != nil {
+			log.Printf("store embedding id=%s: %v", a.ID, err)
+			return
+		}
+		stored.Add(1)
+	})
+	return int(stored.Load())
+}
+
+// embeddingText is the text embedded for an article.
+func embeddingText(a *models.Article) string {
+	return a.Title + "\n\n" + a.Description
+}
+
+// SemanticSearch embeds q and returns the nearest articles by cosine similarity.
+func (s *Service) SemanticSearch(ctx context.Context, q string, limit int) ([]*models.Article, error) {
+	if !s.embeddings {
+		return nil, ErrEmbeddingsDisabled
+	}
+	release, err := s.acquireLLM(ctx)
+	if err != nil {
+		return nil, err
+	}
+	vec, err := s.llmClient.Embed(ctx, q)
+	release()
+	if err != nil {
+		return nil, fmt.Errorf("embed query: %w", err)
+	}
+	return s.repo.SemanticSearch(ctx, vec, limit)
+}
 
-func (s *Service) Nearby(ctx context.Context, lat, lon, radiusKm float64, limit int) ([]*models.Article, error) {
-	// call DB-side optimized query
-	return s.repo.Nearby(lat, lon, radiusKm, limit)
+// autoCategorize fills Categories for uncategorized articles using the LLM.
+// Failures are logged and leave the article uncategorized rather than failing the ingest.
+func (s *Service) autoCategorize(ctx context.Context, articles []*models.Article) {
+	todo := []*models.Article{}
+	for _, a := range articles {
+		if len(a.Categories) == 0 {
+			todo = append(todo, a)
+		}
+	}
+	s.forEachBounded(todo, func(a *models.Article) {
+		release, err := s.acquireLLM(ctx)
+		if err != nil {
+			log.Printf("auto categorize url=%s: %v", a.URL, err)
+			return
+		}
+		cats, err := s.llmClient.Categorize(ctx, a.Title, a.Description)
+		release()
+		if err != nil {
+			log.Printf("auto categorize url=%s: %v", a.URL, err)
+			return
+		}
+		a.Categories = cats
+	})
 }
 
-// helpers
-func haversineKm(lat1, lon1, lat2, lon2 float64) float64 {
-	const R = 6371.0
-	toRad := func(d float64) float64 { return d * math.Pi / 180 }
-	dLat := toRad(lat2 - lat1)
-	dLon := toRad(lon2 - lon1)
-	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
-	c := 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
-	return R * c
+// dedupeByURL makes articles that share a URL with an existing record (or with
+// an earlier article in the same batch) reuse that id, so the upsert updates
+// the story instead of inserting a duplicate. Articles without a URL are untouched.
+func (s *Service) dedupeByURL(ctx context.Context, articles []*models.Article) error {
+	urls := make([]string, 0, len(articles))
+	for _, a := range articles {
+		urls = append(urls, a.URL)
+	}
+	existing, err := s.repo.FindByURLs(ctx, urls)
+	if err != nil {
+		return fmt.Errorf("lookup urls: %w", err)
+	}
+	for _, a := range articles {
+		if a.URL == "" {
+			continue
+		}
+		if id, ok := existing[a.URL]; ok {
+			a.ID = id
+			continue
+		}
+		existing[a.URL] = a.ID
+	}
+	return nil
+}
+
+// Search returns one page of matching articles, the cursor for the next page and
+// the total number of matches. An empty cursor starts from the beginning; an empty
+// next cursor means the last page. Cursors are only supported with the default sort.
+// A positive offset skips that many matches instead (page-number paging); it can't
+// be combined with a cursor and never yields a next cursor.
+// With fuzzy, titles are matched by trigram similarity instead of full-text
+// search, tolerating misspellings (see SetFuzzyThreshold).
+// Results are cached in Redis for the search TTL unless noCache is set.
+func (s *Service) Search(ctx context.Context, q string, f models.ArticleFilter, sort models.SortOrder, limit, offset int, cursor string, fuzzy, noCache bool) ([]*models.Article, string, int, error) {
+	if sort != "" && cursor != "" {
+		return nil, "", 0, fmt.Errorf("%w: cursor requires the default sort", ErrInvalidCursor)
+	}
+	if offset > 0 && cursor != "" {
+		return nil, "", 0, fmt.Errorf("%w: cursor cannot be combined with page", ErrInvalidCursor)
+	}
+	after, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	if noCache || s.rdb == nil || s.searchTTL <= 0 {
+		return s.search(ctx, q, f, sort, limit, offset, after, fuzzy)
+	}
+
+	key, err := s.searchCacheKey(ctx, q, f, sort, limit, offset, cursor, fuzzy)
+	if err != nil {
+		log.Printf("warning: search cache version: %v", err)
+		return s.search(ctx, q, f, sort, limit, offset, after, fuzzy)
+	}
+	var page searchPage
+	if cached, err := s.rdb.Get(ctx, key).Bytes(); err == nil {
+		if err := json.Unmarshal(cached, &page); err == nil {
+			return page.Data, page.Next, page.Total, nil
+		}
+	} else if err != redis.Nil {
+		log.Printf("warning: search cache get: %v", err)
+	}
+
+	res, next, total, err := s.search(ctx, q, f, sort, limit, offset, after, fuzzy)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	if b, err := json.Marshal(searchPage{Data: res, Next: next, Total: total}); err == nil {
+		if err := s.rdb.SetEx(ctx, key, b, s.searchTTL).Err(); err != nil {
+			log.Printf("warning: search cache set: %v", err)
+		}
+	}
+	return res, next, total, nil
+}
+
+// searchPage is the cached form of one Search result page.
+type searchPage struct {
+	Data  []*models.Article `json:"data"`
+	Next  string            `json:"next"`
+	Total int               `json:"total"`
+}
+
+// searchCacheKey builds the cache key for a search under the current cache version.
+// The query is lower-cased and whitespace-collapsed so trivially different
+// spellings share an entry.
+func (s *Service) searchCacheKey(ctx context.Context, q string, f models.ArticleFilter, sort models.SortOrder, limit, offset int, cursor string, fuzzy bool) (string, error) {
+	ver, err := s.rdb.Get(ctx, searchVersionKey).Int64()
+	if err != nil && err != redis.Nil {
+		return "", err
+	}
+	q = strings.ToLower(strings.Join(strings.Fields(q), " "))
+	mode := "exact"
+	if fuzzy {
+		mode = fmt.Sprintf("fuzzy%g", s.fuzzyThreshold)
+	}
+	return fmt.Sprintf("%sv%d:%s:%s:%s:%s:%s:%s:%s:%d:%d:%s:%s:%t", searchKeyPrefix, ver, mode, s.searchWeightsTag(), q, sort,
+		strings.ToLower(strings.Join(f.Sources, ",")), strings.ToLower(f.Language), minRelevanceKey(f), limit, offset, cursor, strings.Join(f.Fields, ","), f.Highlight), nil
+}
+
+// minRelevanceKey renders f.MinRelevance for cache keys ("" when unset).
+func minRelevanceKey(f models.ArticleFilter) string {
+	if f.MinRelevance == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*f.MinRelevance, 'g', -1, 64)
+}
+
+func (s *Service) search(ctx context.Context, q string, f models.ArticleFilter, sort models.SortOrder, limit, offset int, after *models.Cursor, fuzzy bool) ([]*models.Article, string, int, error) {
+	var res []*models.Article
+	var more bool
+	var total int
+	var err error
+	if fuzzy {
+		res, more, err = s.repo.FuzzySearch(ctx, q, s.fuzzyThreshold, f, sort, s.searchWeights, limit, offset, after)
+	} else {
+		res, more, err = s.repo.Search(ctx, q, f, sort, s.searchWeights, limit, offset, after)
+	}
+	if err != nil {
+		return nil, "", 0, err
+	}
+	if fuzzy {
+		total, err = s.repo.CountFuzzySearch(ctx, q, s.fuzzyThreshold, f)
+	} else {
+		total, err = s.repo.CountSearch(ctx, q, f)
+	}
+	if err != nil {
+		return nil, "", 0, fmt.Errorf("count search: %w", err)
+	}
+	next := ""
+	if more && len(res) > 0 && sort == "" && offset == 0 {
+		next = encodeCursor(res[len(res)-1])
+	}
+	return res, next, total, nil
+}
+
+// Category returns articles matching all (matchAll) or any of the given categories,
+// skipping the first offset, plus the total number of matches.
+func (s *Service) Category(ctx context.Context, categories []string, matchAll bool, f models.ArticleFilter, sort models.SortOrder, limit, offset int) ([]*models.Article, int, error) {
+	res, err := s.repo.FindByCategories(ctx, categories, matchAll, f, sort, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := s.repo.CountByCategories(ctx, categories, matchAll, f)
+	if err != nil {
+		return nil, 0, fmt.Errorf("count category: %w", err)
+	}
+	return res, total, nil
+}
+
+// Trending returns the top articles by relevance and recency. With windowHours > 0
+// it only considers articles from the last windowHours, weighted towards the
+// newest (see PgStore.TrendingWindow), and sort is ignored.
+// Results are cached in Redis under trending:<sort>:<window>:<sources>:<lang>:<min_relevance>:<limit>:<fields>; any Redis
+// failure falls back to querying the DB directly.
+func (s *Service) Trending(ctx context.Context, f models.ArticleFilter, sort models.SortOrder, windowHours, limit int) ([]*models.Article, error) {
+	query := func() ([]*models.Article, error) {
+		if windowHours > 0 {
+			return s.repo.TrendingWindow(ctx, windowHours, f, limit)
+		}
+		return s.repo.All(ctx, f, sort, limit)
+	}
+	if s.rdb == nil || s.trendingTTL <= 0 {
+		return query()
+	}
+	key := fmt.Sprintf("%s%s:%d:%s:%s:%s:%d:%s", trendingKeyPrefix, sort, windowHours, strings.ToLower(strings.Join(f.Sources, ",")), strings.ToLower(f.Language), minRelevanceKey(f), limit, strings.Join(f.Fields, ","))
+
+	if cached, err := s.rdb.Get(ctx, key).Bytes(); err == nil {
+		var out []*models.Article
+		if err := json.Unmarshal(cached, &out); err == nil {
+			return out, nil
+		}
+	} else if err != redis.Nil {
+		log.Printf("warning: trending cache get: %v", err)
+	}
+
+	res, err := query()
+	if err != nil {
+		return nil, err
+	}
+	if b, err := json.Marshal(res); err == nil {
+		if err := s.rdb.SetEx(ctx, key, b, s.trendingTTL).Err(); err != nil {
+			log.Printf("warning: trending cache set: %v", err)
+		}
+	}
+	return res, nil
+}
+
+// invalidateCaches drops cached trending and search results after a write.
+func (s *Service) invalidateCaches(ctx context.Context) {
+	s.bustTrendingCache(ctx)
+	s.bumpSearchCache(ctx)
+}
+
+// bumpSearchCache moves search caching to a new version so stale pages are
+// never read again. Errors are only logged.
+func (s *Service) bumpSearchCache(ctx context.Context) {
+	if s.rdb == nil {
+		return
+	}
+	if err := s.rdb.Incr(ctx, searchVersionKey).Err(); err != nil {
+		log.Printf("warning: search cache bump: %v", err)
+	}
+}
+
+// bustTrendingCache removes all cached trending pages. Errors are only logged.
+func (s *Service) bustTrendingCache(ctx context.Context) {
+	if s.rdb == nil {
+		return
+	}
+	iter := s.rdb.Scan(ctx, 0, trendingKeyPrefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		if err := s.rdb.Del(ctx, iter.Val()).Err(); err != nil {
+			log.Printf("warning: trending cache bust: %v", err)
+		}
+	}
+	if err := iter.Err(); err != nil {
+		log.Printf("warning: trending cache bust: %v", err)
+	}
+}
+
+// RecomputeRelevance applies time decay to every article's ingest-time relevance:
+// score = base * 0.5^(age/halfLife), where age is measured from published_at.
+// It always starts from the base score, so running it repeatedly doesn't compound.
+// Returns the number of articles updated.
+func (s *Service) RecomputeRelevance(ctx context.Context) (int64, error) {
+	bases, err := s.repo.RelevanceBases(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("load relevance: %w", err)
+	}
+	now := time.Now().UTC()
+	scores := make(map[string]float64, len(bases))
+	for _, b := range bases {
+		scores[b.ID] = decayedRelevance(b.BaseScore, now.Sub(b.PublishedAt), s.halfLife)
+	}
+	n, err := s.repo.UpdateRelevanceScores(ctx, scores)
+	if err != nil {
+		return 0, fmt.Errorf("update relevance: %w", err)
+	}
+	// trending order depends on relevance_score
+	s.invalidateCaches(ctx)
+	return n, nil
+}
+
+// ReindexReport tells how far a Reindex run got. NextAfter is the cursor to
+// resume from (the last id processed); Done means no articles remain after it.
+type ReindexReport struct {
+	Batches     int    `json:"batches"`
+	Reindexed   int    `json:"reindexed"`
+	Embedded    int    `json:"embedded"`
+	EmbedFailed int    `json:"embed_failed"`
+	NextAfter   string `json:"next_after"`
+	Done        bool   `json:"done"`
+}
+
+// Reindex recomputes the derived columns of the articles with id > after, in
+// id order, batchSize at a time and for at most maxBatches batches, so a large
+// table is worked through over several calls by passing NextAfter back in.
+// The generated search columns are rebuilt in place; with embeddings set, the
+// live articles of each batch are also re-embedded (ErrEmbeddingsDisabled when
+// embeddings aren't configured). Batches commit independently, so on an error
+// the report still tells where to resume. Done is set once the store returns
+// an empty batch.
+func (s *Service) Reindex(ctx context.Context, after string, batchSize, maxBatches int, embeddings bool) (*ReindexReport, error) {
+	if after != "" {
+		if err := checkID(after); err != nil {
+			return nil, err
+		}
+	}
+	if embeddings && !s.embeddings {
+		return nil, ErrEmbeddingsDisabled
+	}
+	rep := &ReindexReport{NextAfter: after}
+	defer func() {
+		if rep.Reindexed > 0 {
+			// ranks and matches may have changed
+			s.invalidateCaches(context.WithoutCancel(ctx))
+		}
+	}()
+	for rep.Batches < maxBatches {
+		batch, err := s.repo.ReindexBatch(ctx, rep.NextAfter, batchSize)
+		if err != nil {
+			return rep, fmt.Errorf("reindex after %q: %w", rep.NextAfter, err)
+		}
+		// only an empty batch ends the run: the store may return fewer rows
+		// than asked for (it caps each query), so a short batch isn't the last
+		if len(batch) == 0 {
+			rep.Done = true
+			break
+		}
+		rep.Batches++
+		rep.Reindexed += len(batch)
+		rep.NextAfter = batch[len(batch)-1].ID
+		if embeddings {
+			live := make([]*models.Article, 0, len(batch))
+			for _, a := range batch {
+				if a.DeletedAt == nil {
+					live = append(live, a)
+				}
+			}
+			n := s.embedArticles(ctx, live)
+			rep.Embedded += n
+			rep.EmbedFailed += len(live) - n
+		}
+		log.Printf("reindex: batch %d, %d articles so far, next_after=%s", rep.Batches, rep.Reindexed, rep.NextAfter)
+		if err := ctx.Err(); err != nil {
+			return rep, err
+		}
+	}
+	return rep, nil
+}
+
+// decayedRelevance halves base every halfLife of age; future-dated articles don't decay.
+func decayedRelevance(base float64, age, halfLife time.Duration) float64 {
+	if age <= 0 || halfLife <= 0 {
+		return base
+	}
+	return base * math.Pow(0.5, age.Hours()/halfLife.Hours())
+}
+
+// Sources lists the publishers in the database with their article counts.
+func (s *Service) Sources(ctx context.Context, limit int) ([]models.SourceCount, error) {
+	return s.repo.Sources(ctx, limit)
+}
+
+// Categories lists the categories in use with their article counts.
+func (s *Service) Categories(ctx context.Context, limit int) ([]models.CategoryCount, error) {
+	return s.repo.DistinctCategories(ctx, limit)
+}
+
+// statsKey caches the Stats result; statsTTL bounds how stale it may be.
+const (
+	statsKey = "stats"
+	statsTTL = time.Minute
+)
+
+// Stats returns aggregate article counts, cached in Redis for a minute.
+func (s *Service) Stats(ctx context.Context) (*models.Stats, error) {
+	if s.rdb != nil {
+		if cached, err := s.rdb.Get(ctx, statsKey).Bytes(); err == nil {
+			var st models.Stats
+			if err := json.Unmarshal(cached, &st); err == nil {
+				return &st, nil
+			}
+		} else if err != redis.Nil {
+			log.Printf("warning: stats cache get: %v", err)
+		}
+	}
+	st, err := s.repo.Stats(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if s.rdb != nil {
+		if b, err := json.Marshal(st); err == nil {
+			if err := s.rdb.SetEx(ctx, statsKey, b, statsTTL).Err(); err != nil {
+				log.Printf("warning: stats cache set: %v", err)
+			}
+		}
+	}
+	return st, nil
+}
+
+// SchemaStatus reports the applied and pending schema migrations.
+func (s *Service) SchemaStatus(ctx context.Context) (*models.SchemaStatus, error) {
+	return s.repo.SchemaStatus(ctx)
+}
+
+// Migrate runs the pending schema migrations, e.g. after a rolling update
+// shipped new ones to replicas that started before it. Returns the ones applied.
+func (s *Service) Migrate(ctx context.Context) ([]models.Migration, error) {
+	return s.repo.Migrate(ctx)
+}
+
+// Nearby returns articles within radiusKm of (lat, lon), closest first.
+// If the database lacks what the distance query needs (models.ErrQueryUnsupported,
+// e.g. PostGIS isn't installed) it falls back to filtering bounding-box
+// candidates in Go with geo.DistanceKm; other errors are returned. partial
+// reports that the fallback had more candidates than it could consider, so
+// some articles within the radius may be missing.
+func (s *Service) Nearby(ctx context.Context, lat, lon, radiusKm float64, limit, offset int, fields []string) (res []*models.Article, partial bool, err error) {
+	if s.postgis {
+		res, err = s.repo.NearbyPostGIS(ctx, lat, lon, radiusKm, limit, offset, fields)
+	} else {
+		// call DB-side optimized query
+		res, err = s.repo.Nearby(ctx, lat, lon, radiusKm, limit, offset, fields)
+	}
+	if !errors.Is(err, models.ErrQueryUnsupported) {
+		return res, false, err
+	}
+	log.Printf("nearby query unsupported, using in-process fallback: %v", err)
+	return s.nearbyFallback(ctx, lat, lon, radiusKm, limit, offset, fields)
+}
+
+// nearbyFallbackCandidates is how many bounding-box rows the Go-side fallback
+// loads, the most the store returns per query.
+const nearbyFallbackCandidates = 1000
+
+// nearbyFallback is the Go-side Nearby: it loads candidates inside the radius'
+// bounding box and keeps those within radiusKm. At most nearbyFallbackCandidates
+// (the most relevant) are considered; partial reports that the box may hold
+// more, i.e. the candidate list came back full.
+func (s *Service) nearbyFallback(ctx context.Context, lat, lon, radiusKm float64, limit, offset int, fields []string) ([]*models.Article, bool, error) {
+	minLat, minLon, maxLat, maxLon := geo.BoundingBox(lat, lon, radiusKm)
+	candidates, err := s.repo.InBoundingBox(ctx, minLat, minLon, maxLat, maxLon, nearbyFallbackCandidates, fields)
+	if err != nil {
+		return nil, false, err
+	}
+	partial := len(candidates) >= nearbyFallbackCandidates
+	if partial {
+		log.Printf("nearby fallback: %d candidates in the bounding box, results may be incomplete", len(candidates))
+	}
+	out := []*models.Article{}
+	for _, a := range candidates {
+		if a.Latitude == nil || a.Longitude == nil {
+			continue
+		}
+		dist := geo.DistanceKm(lat, lon, *a.Latitude, *a.Longitude)
+		if dist <= radiusKm {
+			a.DistanceKm = &dist
+			out = append(out, a)
+		}
+	}
+	// id breaks distance ties like the SQL queries, so pages don't overlap
+	sort.Slice(out, func(i, j int) bool {
+		if *out[i].DistanceKm != *out[j].DistanceKm {
+			return *out[i].DistanceKm < *out[j].DistanceKm
+		}
+		return out[i].ID < out[j].ID
+	})
+
+	if offset >= len(out) {
+		return []*models.Article{}, partial, nil
+	}
+	out = out[offset:]
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out, partial, nil
+}
+
+// InBoundingBox returns articles inside a lat/lon viewport.
+// minLon > maxLon means the box crosses the antimeridian.
+func (s *Service) InBoundingBox(ctx context.Context, minLat, minLon, maxLat, maxLon float64, limit int) ([]*models.Article, error) {
+	return s.repo.InBoundingBox(ctx, minLat, minLon, maxLat, maxLon, limit, nil)
+}
+
+// Similar returns articles sharing categories with id ("more like this"),
+// or ErrNotFound if the article doesn't exist.
+func (s *Service) Similar(ctx context.Context, id string, limit int) ([]*models.Article, error) {
+	res, err := s.repo.SimilarByCategories(ctx, id, limit)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("similar articles: %w", err)
+	}
+	return res, nil
+}
+
+// Archive returns articles published between from and to, oldest first.
+func (s *Service) Archive(ctx context.Context, from, to time.Time, limit, offset int) ([]*models.Article, error) {
+	return s.repo.ArchiveRange(ctx, from, to, limit, offset)
+}
+
+// Recent returns the articles published in the last d, newest first. Unlike
+// Trending it doesn't rank by relevance.
+func (s *Service) Recent(ctx context.Context, d time.Duration, limit int) ([]*models.Article, error) {
+	return s.repo.RecentWithin(ctx, d, limit)
+}
+
+// exportBatchSize is how many articles Export loads per query.
+const exportBatchSize = 500
+
+// Export calls fn for every live article published between from and to (zero
+// times leave that end open), in id order, loading exportBatchSize at a time so
+// the whole table is never held in memory. flush, when non-nil, runs after each
+// batch. It stops at the first error from the store, fn or flush.
+func (s *Service) Export(ctx context.Context, from, to time.Time, fn func(*models.Article) error, flush func()) error {
+	after := ""
+	for {
+		batch, err := s.repo.ExportPage(ctx, from, to, after, exportBatchSize)
+		if err != nil {
+			return fmt.Errorf("export: %w", err)
+		}
+		for _, a := range batch {
+			if err := fn(a); err != nil {
+				return err
+			}
+		}
+		if flush != nil {
+			flush()
+		}
+		if len(batch) < exportBatchSize {
+			return nil
+		}
+		after = batch[len(batch)-1].ID
+	}
 }
@@ -0,0 +1,324 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/nitesh/news_service/internal/llm"
+	"github.com/nitesh/news_service/pkg/models"
+)
+
+// fakeStore is an ArticleStore for tests. Methods a test doesn't set up panic
+// through the nil embedded interface.
+type fakeStore struct {
+	ArticleStore
+
+	nearbyErr  error
+	nearby     []*models.Article
+	bbox       []*models.Article
+	bboxCalls  int
+	bboxLimit  int
+	bboxFields []string
+
+	reindexRows  int // articles ReindexBatch pages through
+	reindexCalls int
+
+	article      *models.Article // returned by GetByIDs
+	savedSummary string
+	savedModel   string
+}
+
+func (f *fakeStore) GetByIDs(ctx context.Context, ids []string) ([]*models.Article, error) {
+	return []*models.Article{f.article}, nil
+}
+
+func (f *fakeStore) UpdateLLMSummary(ctx context.Context, id, summary, model string) error {
+	f.savedSummary, f.savedModel = summary, model
+	return nil
+}
+
+func (f *fakeStore) Nearby(ctx context.Context, lat, lon, radiusKm float64, limit, offset int, fields []string) ([]*models.Article, error) {
+	return f.nearby, f.nearbyErr
+}
+
+func (f *fakeStore) NearbyPostGIS(ctx context.Context, lat, lon, radiusKm float64, limit, offset int, fields []string) ([]*models.Article, error) {
+	return f.nearby, f.nearbyErr
+}
+
+func (f *fakeStore) InBoundingBox(ctx context.Context, minLat, minLon, maxLat, maxLon float64, limit int, fields []string) ([]*models.Article, error) {
+	f.bboxCalls++
+	f.bboxLimit, f.bboxFields = limit, fields
+	return f.bbox, nil
+}
+
+// storeRowCap mirrors the store's per-query row ceiling (store.MaxQueryRows).
+const storeRowCap = 1000
+
+// ReindexBatch pages through reindexRows articles with ids "00000000".."n-1",
+// returning at most storeRowCap per call like the real store.
+func (f *fakeStore) ReindexBatch(ctx context.Context, after string, limit int) ([]*models.Article, error) {
+	f.reindexCalls++
+	start := 0
+	if after != "" {
+		fmt.Sscanf(after, "%d", &start)
+		start++
+	}
+	n := min(limit, storeRowCap, max(f.reindexRows-start, 0))
+	out := make([]*models.Article, n)
+	for i := range out {
+		out[i] = &models.Article{ID: fmt.Sprintf("%08d", start+i)}
+	}
+	return out, nil
+}
+
+func point(id string, lat, lon float64) *models.Article {
+	return &models.Article{ID: id, Latitude: &lat, Longitude: &lon}
+}
+
+// unsupportedErr is what the store returns when PostGIS or the distance
+// function is missing.
+var unsupportedErr = fmt.Errorf("%w: pq: function st_dwithin does not exist", models.ErrQueryUnsupported)
+
+func articleIDs(articles []*models.Article) []string {
+	out := make([]string, len(articles))
+	for i, a := range articles {
+		out[i] = a.ID
+	}
+	return out
+}
+
+func TestNearbyFallback(t *testing.T) {
+	// around Bangalore (12.97, 77.59): c is ~3.5km away, a ~5.4km, b ~7.8km, far ~290km
+	candidates := []*models.Article{
+		point("b", 13.04, 77.59),
+		point("far", 13.08, 80.27),
+		point("a", 12.97, 77.64),
+		{ID: "no-coords"},
+		point("c", 12.94, 77.60),
+	}
+
+	tests := []struct {
+		name          string
+		postgis       bool
+		limit, offset int
+		want          []string
+	}{
+		{"closest first", false, 10, 0, []string{"c", "a", "b"}},
+		{"postgis unavailable", true, 10, 0, []string{"c", "a", "b"}},
+		{"limit", false, 2, 0, []string{"c", "a"}},
+		{"offset", false, 10, 1, []string{"a", "b"}},
+		{"offset past the end", false, 10, 5, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeStore{nearbyErr: unsupportedErr, bbox: candidates}
+			s := NewService(repo, nil, nil)
+			s.SetPostGISEnabled(tt.postgis)
+
+			got, partial, err := s.Nearby(context.Background(), 12.97, 77.59, 10, tt.limit, tt.offset, []string{"title"})
+			if err != nil {
+				t.Fatalf("Nearby: %v", err)
+			}
+			if partial {
+				t.Error("partial = true for a short candidate list")
+			}
+			if fmt.Sprint(articleIDs(got)) != fmt.Sprint(tt.want) {
+				t.Errorf("Nearby = %v, want %v", articleIDs(got), tt.want)
+			}
+			for _, a := range got {
+				if a.DistanceKm == nil || *a.DistanceKm > 10 {
+					t.Errorf("article %s distance_km = %v, want <= 10", a.ID, a.DistanceKm)
+				}
+			}
+			if fmt.Sprint(repo.bboxFields) != "[title]" {
+				t.Errorf("fallback selected fields %v, want [title]", repo.bboxFields)
+			}
+		})
+	}
+}
+
+func TestNearbyFallbackPartial(t *testing.T) {
+	candidates := make([]*models.Article, nearbyFallbackCandidates)
+	for i := range candidates {
+		candidates[i] = point(fmt.Sprintf("%04d", i), 12.97, 77.59)
+	}
+	repo := &fakeStore{nearbyErr: unsupportedErr, bbox: candidates}
+	got, partial, err := NewService(repo, nil, nil).Nearby(context.Background(), 12.97, 77.59, 10, 20, 0, nil)
+	if err != nil {
+		t.Fatalf("Nearby: %v", err)
+	}
+	if !partial {
+		t.Error("partial = false for a full candidate list")
+	}
+	if len(got) != 20 {
+		t.Errorf("got %d articles, want 20", len(got))
+	}
+	if repo.bboxLimit != nearbyFallbackCandidates {
+		t.Errorf("candidate limit = %d, want %d", repo.bboxLimit, nearbyFallbackCandidates)
+	}
+}
+
+func TestNearbyErrors(t *testing.T) {
+	dbErr := errors.New("pq: canceling statement due to statement timeout")
+	tests := []struct {
+		name         string
+		err          error
+		wantErr      error
+		wantFallback bool
+	}{
+		{"success uses the query result", nil, nil, false},
+		{"other errors are returned", dbErr, dbErr, false},
+		{"cancellation is returned", context.Canceled, context.Canceled, false},
+		{"unsupported falls back", unsupportedErr, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeStore{nearbyErr: tt.err, nearby: []*models.Article{point("db", 12.97, 77.59)}}
+			_, _, err := NewService(repo, nil, nil).Nearby(context.Background(), 12.97, 77.59, 10, 20, 0, nil)
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
+			}
+			if fellBack := repo.bboxCalls > 0; fellBack != tt.wantFallback {
+				t.Errorf("fell back = %v, want %v", fellBack, tt.wantFallback)
+			}
+		})
+	}
+}
+
+func TestReindexDone(t *testing.T) {
+	tests := []struct {
+		name          string
+		rows          int
+		batchSize     int
+		maxBatches    int
+		wantReindexed int
+		wantBatches   int
+		wantDone      bool
+		wantNextAfter string
+	}{
+		{"empty table", 0, 500, 20, 0, 0, true, ""},
+		{"short last batch", 1200, 500, 20, 1200, 3, true, "00001199"},
+		{"exact multiple", 1000, 500, 20, 1000, 2, true, "00000999"},
+		{"batch size above the store cap", 2500, 5000, 20, 2500, 3, true, "00002499"},
+		{"stops at max batches", 2500, 500, 2, 1000, 2, false, "00000999"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeStore{reindexRows: tt.rows}
+			rep, err := NewService(repo, nil, nil).Reindex(context.Background(), "", tt.batchSize, tt.maxBatches, false)
+			if err != nil {
+				t.Fatalf("Reindex: %v", err)
+			}
+			if rep.Reindexed != tt.wantReindexed || rep.Batches != tt.wantBatches || rep.Done != tt.wantDone || rep.NextAfter != tt.wantNextAfter {
+				t.Errorf("report = %+v, want reindexed=%d batches=%d done=%v next_after=%q",
+					rep, tt.wantReindexed, tt.wantBatches, tt.wantDone, tt.wantNextAfter)
+			}
+		})
+	}
+}
+
+// fakeCache answers GET and SETEX from a map without a Redis server.
+type fakeCache map[string]string
+
+func (f fakeCache) DialHook(next redis.DialHook) redis.DialHook { return next }
+
+func (f fakeCache) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return next
+}
+
+func (f fakeCache) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		args := cmd.Args()
+		switch cmd.Name() {
+		case "get":
+			v, ok := f[args[1].(string)]
+			if !ok {
+				cmd.SetErr(redis.Nil)
+				return redis.Nil
+			}
+			cmd.(*redis.StringCmd).SetVal(v)
+		case "setex":
+			f[args[1].(string)] = args[3].(string)
+			cmd.(*redis.StatusCmd).SetVal("OK")
+		}
+		return nil
+	}
+}
+
+// summaryLLM is an Ollama-style server that fails requests for failModel and
+// answers the others with "summary by <model>", counting the calls.
+func summaryLLM(t *testing.T, failModel string, calls *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*calls++
+		var req struct {
+			Model string `json:"model"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		if req.Model == failModel {
+			http.Error(w, "model overloaded", http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"response": "summary by " + req.Model})
+	}))
+}
+
+func TestSummaryModelProvenance(t *testing.T) {
+	tests := []struct {
+		name       string
+		failModel  string
+		wantModel  string
+		wantCached bool // whether the second request is served from the cache
+	}{
+		{"primary model", "", "primary", true},
+		{"fallback model", "primary", "small", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			srv := summaryLLM(t, tt.failModel, &calls)
+			defer srv.Close()
+			client, err := llm.NewClient(srv.URL, "primary", "", srv.Client())
+			if err != nil {
+				t.Fatalf("NewClient: %v", err)
+			}
+			client.SetFallbackModel("small")
+			cache := fakeCache{}
+			rdb := redis.NewClient(&redis.Options{Addr: "redis.invalid:6379"})
+			rdb.AddHook(cache)
+			repo := &fakeStore{article: &models.Article{ID: "00000000-0000-0000-0000-000000000001", Title: "t", Content: "c"}}
+			s := NewService(repo, rdb, client)
+
+			summary, cached, model, err := s.SummarizeArticle(context.Background(), repo.article.ID, SummaryOptions{Force: true})
+			if err != nil {
+				t.Fatalf("SummarizeArticle: %v", err)
+			}
+			if cached || model != tt.wantModel || summary != "summary by "+tt.wantModel {
+				t.Errorf("SummarizeArticle = %q, cached %v, model %q, want a fresh summary by %s", summary, cached, model, tt.wantModel)
+			}
+			if repo.savedSummary != summary || repo.savedModel != tt.wantModel {
+				t.Errorf("saved %q by %q, want %q by %s", repo.savedSummary, repo.savedModel, summary, tt.wantModel)
+			}
+
+			before := calls
+			_, cached, model, err = s.SummarizeArticle(context.Background(), repo.article.ID, SummaryOptions{Force: true})
+			if err != nil {
+				t.Fatalf("SummarizeArticle again: %v", err)
+			}
+			if cached != tt.wantCached || (calls == before) != tt.wantCached {
+				t.Errorf("second request cached = %v after %d LLM calls, want cached = %v", cached, calls-before, tt.wantCached)
+			}
+			if model != tt.wantModel {
+				t.Errorf("second request model = %q, want %q", model, tt.wantModel)
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrQueueUnavailable is returned when async summaries are requested without Redis.
+var ErrQueueUnavailable = errors.New("summary queue unavailable")
+
+// summaryQueueKey is the Redis list holding pending summary jobs (LPUSH / BRPOP).
+const summaryQueueKey = "summary:queue"
+
+// summaryStatusPrefix namespaces per-article job state (summary:status:<article id>).
+const summaryStatusPrefix = "summary:status:"
+
+// summaryStatusTTL bounds how long a pending/failed status lingers if a worker dies.
+const summaryStatusTTL = time.Hour
+
+// summaryJobTimeout bounds one queued summarization.
+const summaryJobTimeout = 2 * time.Minute
+
+// Summary job states reported by SummaryStatus.
+const (
+	SummaryPending = "pending"
+	SummaryFailed  = "failed"
+	SummaryDone    = "done"
+	SummaryNone    = "none"
+)
+
+type summaryJob struct {
+	JobID     string `json:"job_id"`
+	ArticleID string `json:"article_id"`
+	Model     string `json:"model,omitempty"`
+	Length    string `json:"length,omitempty"`
+	Force     bool   `json:"force,omitempty"`
+}
+
+// EnqueueSummary queues an LLM summary for article id (see SummarizeArticle for
+// opts) and returns the job id. Unless opts.Force is set, an article that
+// already has a summary isn't queued: the job id is empty and its stored
+// summary is returned instead. It returns ErrNotFound for unknown articles and
+// ErrQueueUnavailable without Redis.
+func (s *Service) EnqueueSummary(ctx context.Context, id string, opts SummaryOptions) (jobID, existing string, err error) {
+	if s.rdb == nil {
+		return "", "", ErrQueueUnavailable
+	}
+	opts, err = s.checkSummaryOptions(opts)
+	if err != nil {
+		return "", "", err
+	}
+	art, err := s.GetArticle(ctx, id)
+	if err != nil {
+		return "", "", err
+	}
+	if !opts.Force && art.LLMSummary != "" {
+		return "", art.LLMSummary, nil
+	}
+	jobID, err = s.enqueueSummary(ctx, id, opts)
+	return jobID, "", err
+}
+
+// enqueueSummary pushes a job for an article known to exist.
+func (s *Service) enqueueSummary(ctx context.Context, id string, opts SummaryOptions) (string, error) {
+	job := summaryJob{JobID: uuid.New().String(), ArticleID: id, Model: opts.Model, Length: opts.Length, Force: opts.Force}
+	b, err := json.Marshal(job)
+	if err != nil {
+		return "", err
+	}
+	if err := s.rdb.SetEx(ctx, summaryStatusPrefix+id, SummaryPending, summaryStatusTTL).Err(); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrQueueUnavailable, err)
+	}
+	if err := s.rdb.LPush(ctx, summaryQueueKey, b).Err(); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrQueueUnavailable, err)
+	}
+	return job.JobID, nil
+}
+
+// SummaryStatus returns the stored summary of article id and its job state:
+// SummaryPending or SummaryFailed while a queued job is outstanding or failed,
+// otherwise SummaryDone (a summary is stored) or SummaryNone.
+func (s *Service) SummaryStatus(ctx context.Context, id string) (string, string, error) {
+	art, err := s.GetArticle(ctx, id)
+	if err != nil {
+		return "", "", err
+	}
+	if s.rdb != nil {
+		state, err := s.rdb.Get(ctx, summaryStatusPrefix+id).Result()
+		if err == nil && (state == SummaryPending || state == SummaryFailed) {
+			return art.LLMSummary, state, nil
+		}
+		if err != nil && !errors.Is(err, redis.Nil) {
+			log.Printf("summary status id=%s: %v", id, err)
+		}
+	}
+	if art.LLMSummary != "" {
+		return art.LLMSummary, SummaryDone, nil
+	}
+	return "", SummaryNone, nil
+}
+
+// StartSummaryWorkers starts n goroutines that pop queued summary jobs until
+// ctx is cancelled. The returned func blocks until they have exited.
+func (s *Service) StartSummaryWorkers(ctx context.Context, n int) (wait func()) {
+	var wg sync.WaitGroup
+	if s.rdb == nil || n < 1 {
+		return wg.Wait
+	}
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.summaryWorker(ctx)
+		}()
+	}
+	return wg.Wait
+}
+
+// summaryWorker pops jobs (blocking up to a few seconds at a time so it notices
+// cancellation) and summarizes them.
+func (s *Service) summaryWorker(ctx context.Context) {
+	for ctx.Err() == nil {
+		res, err := s.rdb.BRPop(ctx, 5*time.Second, summaryQueueKey).Result()
+		if err != nil {
+			if !errors.Is(err, redis.Nil) && ctx.Err() == nil {
+				log.Printf("summary worker: pop: %v", err)
+				time.Sleep(time.Second)
+			}
+			continue
+		}
+		// res is [key, value]
+		var job summaryJob
+		if err := json.Unmarshal([]byte(res[1]), &job); err != nil {
+			log.Printf("summary worker: bad job %q: %v", res[1], err)
+			continue
+		}
+		s.runSummaryJob(ctx, job)
+	}
+}
+
+func (s *Service) runSummaryJob(ctx context.Context, job summaryJob) {
+	jobCtx, cancel := context.WithTimeout(ctx, summaryJobTimeout)
+	defer cancel()
+	statusKey := summaryStatusPrefix + job.ArticleID
+	opts := SummaryOptions{Model: job.Model, Length: job.Length, Force: job.Force}
+	if _, _, _, err := s.SummarizeArticle(jobCtx, job.ArticleID, opts); err != nil {
+		log.Printf("summary job %s id=%s: %v", job.JobID, job.ArticleID, err)
+		if err := s.rdb.SetEx(ctx, statusKey, SummaryFailed, summaryStatusTTL).Err(); err != nil {
+			log.Printf("summary job %s: set status: %v", job.JobID, err)
+		}
+		return
+	}
+	if err := s.rdb.Del(ctx, statusKey).Err(); err != nil {
+		log.Printf("summary job %s: clear status: %v", job.JobID, err)
+	}
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+
+	"github.com/google/uuid"
+	"github.com/nitesh/news_service/pkg/models"
+)
+
+// ArticleError describes why one posted article is invalid.
+type ArticleError struct {
+	Index   int    `json:"index"`
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError is returned by Ingest when one or more articles are invalid.
+// Nothing is written in that case.
+type ValidationError struct {
+	Errors []ArticleError
+}
+
+func (e *ValidationError) Error() string {
+	if len(e.Errors) == 1 {
+		a := e.Errors[0]
+		return fmt.Sprintf("invalid article %d: %s %s", a.Index, a.Field, a.Message)
+	}
+	return fmt.Sprintf("%d invalid article fields", len(e.Errors))
+}
+
+// validateArticles checks the fields that would otherwise be rejected by the
+// database or produce unusable records. Indexes refer to positions in articles.
+func validateArticles(articles []*models.Article) []ArticleError {
+	errs := []ArticleError{}
+	for i, a := range articles {
+		if a == nil {
+			errs = append(errs, ArticleError{Index: i, Field: "article", Message: "must not be null"})
+			continue
+		}
+		if a.ID != "" {
+			if _, err := uuid.Parse(a.ID); err != nil {
+				errs = append(errs, ArticleError{Index: i, Field: "id", Message: "must be a UUID"})
+			}
+		}
+		if a.Title == "" && a.Description == "" {
+			errs = append(errs, ArticleError{Index: i, Field: "title", Message: "title or description is required"})
+		}
+		if a.URL != "" {
+			u, err := url.Parse(a.URL)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				errs = append(errs, ArticleError{Index: i, Field: "url", Message: "must be an absolute http(s) URL"})
+			}
+		}
+		if (a.Latitude == nil) != (a.Longitude == nil) {
+			errs = append(errs, ArticleError{Index: i, Field: "latitude", Message: "latitude and longitude must be set together"})
+		}
+		if a.Latitude != nil && math.Abs(*a.Latitude) > 90 {
+			errs = append(errs, ArticleError{Index: i, Field: "latitude", Message: "must be between -90 and 90"})
+		}
+		if a.Longitude != nil && math.Abs(*a.Longitude) > 180 {
+			errs = append(errs, ArticleError{Index: i, Field: "longitude", Message: "must be between -180 and 180"})
+		}
+	}
+	return errs
+}
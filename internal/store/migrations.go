@@ -0,0 +1,225 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"github.com/nitesh/news_service/pkg/models"
+)
+
+// migration is one versioned schema change. The SQL mirrors migrations/NNN_name.sql
+// and is written to be idempotent, so databases created before versioning can
+// simply run everything once to be recorded.
+type migration struct {
+	version int
+	name    string
+	sql     string
+}
+
+// migrationLockID is the pg_advisory_lock key held while migrations run.
+const migrationLockID = 7310422
+
+const schemaMigrationsSQL = `
+CREATE TABLE IF NOT EXISTS schema_migrations(
+  version INT PRIMARY KEY,
+  name TEXT NOT NULL,
+  applied_at TIMESTAMP NOT NULL DEFAULT now()
+);
+`
+
+// coreMigrations run on every startup and via POST /v1/admin/migrate, in order.
+var coreMigrations = []migration{
+	{1, "init", `
+CREATE TABLE IF NOT EXISTS articles(
+  id UUID PRIMARY KEY,
+  title TEXT,
+  description TEXT,
+  url TEXT,
+  published_at TIMESTAMP,
+  source TEXT,
+  categories JSONB,
+  relevance_score DOUBLE PRECISION DEFAULT 0,
+  latitude DOUBLE PRECISION,
+  longitude DOUBLE PRECISION,
+  llm_summary TEXT,
+  created_at TIMESTAMP DEFAULT now(),
+  updated_at TIMESTAMP DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS idx_articles_published ON articles(published_at);
+CREATE INDEX IF NOT EXISTS idx_articles_relevance ON articles(relevance_score);
+CREATE INDEX IF NOT EXISTS idx_articles_source ON articles(source);
+-- GIN index for jsonb array search on categories
+CREATE INDEX IF NOT EXISTS idx_articles_categories ON articles USING GIN (categories);
+`},
+	// bookkeeping columns for tables created before they existed; existing rows get now()
+	{2, "article_timestamps", `
+ALTER TABLE articles ADD COLUMN IF NOT EXISTS created_at TIMESTAMP DEFAULT now();
+ALTER TABLE articles ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP DEFAULT now();
+`},
+	// used by FindByURLs to dedupe ingests of the same story
+	{3, "articles_url_index", `
+CREATE INDEX IF NOT EXISTS idx_articles_url ON articles(url);
+`},
+	// full-text search over title + description (stemmed, english dictionary)
+	{4, "articles_search_vector", `
+ALTER TABLE articles ADD COLUMN IF NOT EXISTS search_vector tsvector
+  GENERATED ALWAYS AS (to_tsvector('english', coalesce(title, '') || ' ' || coalesce(description, ''))) STORED;
+CREATE INDEX IF NOT EXISTS idx_articles_search_vector ON articles USING GIN (search_vector);
+`},
+	// ingest-time relevance; relevance_score is recomputed from it as articles age
+	{5, "articles_base_relevance", `
+ALTER TABLE articles ADD COLUMN IF NOT EXISTS base_relevance_score DOUBLE PRECISION;
+UPDATE articles SET base_relevance_score = relevance_score WHERE base_relevance_score IS NULL;
+`},
+	// 6 and 7 are optional, see embeddingMigration and geogMigration
+	// soft delete: rows with deleted_at set are hidden from every read query
+	{8, "articles_deleted_at", `
+ALTER TABLE articles ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP NULL;
+CREATE INDEX IF NOT EXISTS idx_articles_deleted_at ON articles(deleted_at) WHERE deleted_at IS NOT NULL;
+`},
+	// ISO 639 language code detected (or supplied) at ingest; 'und' when undetermined
+	{9, "articles_language", `
+ALTER TABLE articles ADD COLUMN IF NOT EXISTS language TEXT NOT NULL DEFAULT 'und';
+CREATE INDEX IF NOT EXISTS idx_articles_language ON articles(language);
+`},
+	{10, "schema_migrations", schemaMigrationsSQL},
+	// trigram index for fuzzy (typo-tolerant) title search; pg_trgm ships with Postgres contrib
+	{11, "articles_title_trgm", `
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE INDEX IF NOT EXISTS idx_articles_title_trgm ON articles USING GIN (title gin_trgm_ops);
+`},
+	// articles ingested without coordinates used to be stored as 0,0; make them unset
+	{12, "articles_null_zero_geo", `
+UPDATE articles SET latitude = NULL, longitude = NULL WHERE latitude = 0 AND longitude = 0;
+`},
+	// full article body; summaries prefer it over the description
+	{13, "articles_content", `
+ALTER TABLE articles ADD COLUMN IF NOT EXISTS content TEXT NOT NULL DEFAULT '';
+`},
+	// provenance of llm_summary, set by UpdateLLMSummary
+	{14, "articles_summary_model", `
+ALTER TABLE articles ADD COLUMN IF NOT EXISTS summary_model TEXT NOT NULL DEFAULT '';
+`},
+}
+
+// Optional migrations are applied by RunVectorMigrations / RunPostGISMigrations
+// and only recorded here.
+var (
+	embeddingMigration = migration{version: 6, name: "articles_embedding"}
+	geogMigration      = migration{version: 7, name: "articles_geog"}
+)
+
+// applyPending runs the core migrations not yet in schema_migrations, each in
+// its own transaction together with its version row, and returns the ones it
+// applied. It holds a session advisory lock for the duration.
+func applyPending(ctx context.Context, db *sql.DB) ([]models.Migration, error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLockID); err != nil {
+		return nil, fmt.Errorf("migration lock: %w", err)
+	}
+	// unlock even if ctx was cancelled mid-way
+	defer conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockID)
+
+	if _, err := conn.ExecContext(ctx, schemaMigrationsSQL); err != nil {
+		return nil, err
+	}
+	rows, err := conn.QueryContext(ctx, `SELECT version FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	done := map[int]bool{}
+	for rows.Next() {
+		var v int
+		if err := rows.Scan(&v); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		done[v] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	applied := []models.Migration{}
+	for _, m := range coreMigrations {
+		if done[m.version] {
+			continue
+		}
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			return applied, err
+		}
+		if _, err := tx.ExecContext(ctx, m.sql); err != nil {
+			tx.Rollback()
+			return applied, fmt.Errorf("migration %03d_%s: %w", m.version, m.name, err)
+		}
+		var at models.Migration
+		err = tx.QueryRowContext(ctx, `
+INSERT INTO schema_migrations (version, name) VALUES ($1, $2)
+RETURNING version, name, applied_at`, m.version, m.name).Scan(&at.Version, &at.Name, &at.AppliedAt)
+		if err != nil {
+			tx.Rollback()
+			return applied, fmt.Errorf("record migration %03d_%s: %w", m.version, m.name, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return applied, err
+		}
+		applied = append(applied, at)
+	}
+	return applied, nil
+}
+
+// recordMigration marks an optional migration as applied (a no-op if it already is).
+func recordMigration(ctx context.Context, db *sql.DB, m migration) error {
+	if _, err := db.ExecContext(ctx, schemaMigrationsSQL); err != nil {
+		return err
+	}
+	_, err := db.ExecContext(ctx, `
+INSERT INTO schema_migrations (version, name) VALUES ($1, $2)
+ON CONFLICT (version) DO NOTHING`, m.version, m.name)
+	return err
+}
+
+// SchemaStatus lists the applied migrations and the core ones still pending.
+func (p *PgStore) SchemaStatus(ctx context.Context) (*models.SchemaStatus, error) {
+	st := &models.SchemaStatus{Applied: []models.Migration{}, Pending: []models.Migration{}}
+	err := p.db.SelectContext(ctx, &st.Applied, `SELECT version, name, applied_at FROM schema_migrations ORDER BY version`)
+	if err != nil && !isUndefinedTable(err) {
+		return nil, err
+	}
+	done := map[int]bool{}
+	for _, m := range st.Applied {
+		done[m.Version] = true
+		if m.Version > st.Version {
+			st.Version = m.Version
+		}
+	}
+	for _, m := range coreMigrations {
+		if !done[m.version] {
+			st.Pending = append(st.Pending, models.Migration{Version: m.version, Name: m.name})
+		}
+	}
+	return st, nil
+}
+
+// Migrate applies the pending core migrations and returns the ones it ran.
+func (p *PgStore) Migrate(ctx context.Context) ([]models.Migration, error) {
+	return applyPending(ctx, p.db.DB)
+}
+
+// isUndefinedTable reports whether err is Postgres undefined_table (42P01),
+// i.e. schema_migrations hasn't been created yet.
+func isUndefinedTable(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "42P01"
+}
@@ -1,73 +1,198 @@
 package store
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 
 	dbtypes "github.com/nitesh/news_service/internal/db"
+	"github.com/nitesh/news_service/internal/lang"
 	"github.com/nitesh/news_service/pkg/models"
 )
 
 type PgStore struct {
-	db *sqlx.DB
+	db        *sqlx.DB
+	chunkSize int
+	// runTx runs fn in a transaction (see serializableTx); tests replace it to
+	// simulate begin/commit failures without a database.
+	runTx func(ctx context.Context, fn func(tx *sqlx.Tx) error) error
 }
 
+// defaultChunkSize is how many articles SaveMany writes per transaction.
+const defaultChunkSize = 500
+
 func NewPgStore(db *sql.DB) *PgStore {
-	return &PgStore{db: sqlx.NewDb(db, "postgres")}
+	p := &PgStore{db: sqlx.NewDb(db, "postgres"), chunkSize: defaultChunkSize}
+	p.runTx = p.serializableTx
+	return p
+}
+
+// SetChunkSize sets how many articles SaveMany commits per transaction.
+// Values below 1 are ignored.
+func (p *PgStore) SetChunkSize(n int) {
+	if n < 1 {
+		return
+	}
+	p.chunkSize = n
+}
+
+// DB returns the underlying *sql.DB (e.g. for health checks).
+func (p *PgStore) DB() *sql.DB {
+	return p.db.DB
 }
 
+// RunMigrations applies the pending core migrations (see migrations.go) and
+// records them in schema_migrations. Replicas starting together serialize on
+// an advisory lock, so each migration runs once.
 func RunMigrations(db *sql.DB) error {
-	initSQL := `
-CREATE TABLE IF NOT EXISTS articles(
-  id UUID PRIMARY KEY,
-  title TEXT,
-  description TEXT,
-  url TEXT,
-  published_at TIMESTAMP,
-  source TEXT,
-  categories JSONB,
-  relevance_score DOUBLE PRECISION DEFAULT 0,
-  latitude DOUBLE PRECISION,
-  longitude DOUBLE PRECISION,
-  llm_summary TEXT
-);
-
-CREATE INDEX IF NOT EXISTS idx_articles_published ON articles(published_at);
-CREATE INDEX IF NOT EXISTS idx_articles_relevance ON articles(relevance_score);
-CREATE INDEX IF NOT EXISTS idx_articles_source ON articles(source);
--- GIN index for jsonb array search on categories
-CREATE INDEX IF NOT EXISTS idx_articles_categories ON articles USING GIN (categories);
-`
-	_, err := db.Exec(initSQL)
+	_, err := applyPending(context.Background(), db)
 	return err
 }
 
+// RunVectorMigrations enables the pgvector extension and adds the embedding column
+// used by SemanticSearch. It needs pgvector (>= 0.5 for HNSW) installed on the
+// Postgres server, so it's only run when embeddings are enabled.
+func RunVectorMigrations(db *sql.DB, dims int) error {
+	vecSQL := fmt.Sprintf(`
+CREATE EXTENSION IF NOT EXISTS vector;
+ALTER TABLE articles ADD COLUMN IF NOT EXISTS embedding vector(%d);
+CREATE INDEX IF NOT EXISTS idx_articles_embedding ON articles USING hnsw (embedding vector_cosine_ops);
+`, dims)
+	if _, err := db.Exec(vecSQL); err != nil {
+		return err
+	}
+	return recordMigration(context.Background(), db, embeddingMigration)
+}
+
+// RunPostGISMigrations enables PostGIS and adds a geography point generated from
+// latitude/longitude (so ingest and updates keep it in sync) plus a GiST index
+// used by NearbyPostGIS. It needs the postgis extension on the Postgres server,
+// so it's only run when GEO_BACKEND=postgis.
+func RunPostGISMigrations(db *sql.DB) error {
+	geoSQL := `
+CREATE EXTENSION IF NOT EXISTS postgis;
+ALTER TABLE articles ADD COLUMN IF NOT EXISTS geog geography(Point, 4326)
+  GENERATED ALWAYS AS (
+    CASE WHEN latitude IS NOT NULL AND longitude IS NOT NULL
+      THEN ST_SetSRID(ST_MakePoint(longitude, latitude), 4326)::geography
+    END) STORED;
+CREATE INDEX IF NOT EXISTS idx_articles_geog ON articles USING GIST (geog);
+`
+	if _, err := db.Exec(geoSQL); err != nil {
+		return err
+	}
+	return recordMigration(context.Background(), db, geogMigration)
+}
+
 // SaveMany replaces any NamedExec-based insert for articles and writes categories as jsonb.
 // It expects models.Article.Categories to be of type dbtypes.StringSlice (implements driver.Valuer).
-func (p *PgStore) SaveMany(articles []*models.Article) error {
-	tx, err := p.db.Beginx()
+// Articles are committed in chunks (see SetChunkSize) so a large batch doesn't hold one
+// long transaction; if a later chunk fails, earlier chunks stay committed and a
+// *models.PartialSaveError says how far it got.
+func (p *PgStore) SaveMany(ctx context.Context, articles []*models.Article) error {
+	total := (len(articles) + p.chunkSize - 1) / p.chunkSize
+	for i := 0; i < total; i++ {
+		start := i * p.chunkSize
+		end := start + p.chunkSize
+		if end > len(articles) {
+			end = len(articles)
+		}
+		chunk := articles[start:end]
+		err := withTxRetry(ctx, func() error {
+			return p.runTx(ctx, func(tx *sqlx.Tx) error { return saveChunk(ctx, tx, chunk) })
+		})
+		if err != nil {
+			if i == 0 {
+				return err
+			}
+			return &models.PartialSaveError{ChunksCommitted: i, ChunksTotal: total, Saved: start, Err: err}
+		}
+	}
+	return nil
+}
+
+// txMaxAttempts is how many times withTxRetry runs a transaction that keeps
+// failing with a retryable error.
+const txMaxAttempts = 3
+
+// txRetryBackoff is the base delay between attempts (multiplied by the attempt number).
+const txRetryBackoff = 50 * time.Millisecond
+
+// withTxRetry runs fn, which must run (and roll back on failure) a whole
+// transaction, retrying it when Postgres aborts it with serialization_failure
+// (40001) or deadlock_detected (40P01). Other errors are returned immediately.
+func withTxRetry(ctx context.Context, fn func() error) error {
+	var err error
+	for attempt := 1; attempt <= txMaxAttempts; attempt++ {
+		if err = fn(); err == nil || !isRetryable(err) || attempt == txMaxAttempts {
+			return err
+		}
+		select {
+		case <-time.After(time.Duration(attempt) * txRetryBackoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return err
+}
+
+// serializableTx runs fn in a SERIALIZABLE transaction, committing when it
+// succeeds and rolling back when it (or the commit) fails. Conflicting
+// concurrent upserts then abort with 40001, which withTxRetry retries.
+func (p *PgStore) serializableTx(ctx context.Context, fn func(tx *sqlx.Tx) error) error {
+	tx, err := p.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
 	if err != nil {
 		return err
 	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
 
+// isRetryable reports whether err is a Postgres serialization failure or deadlock.
+func isRetryable(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	return pqErr.Code == "40001" || pqErr.Code == "40P01"
+}
+
+// saveChunk upserts articles within tx. A re-ingested article without a
+// summary or content keeps the stored ones, as Update does; a supplied summary
+// clears summary_model, since no model of ours generated it. Posting the id of
+// a soft-deleted article fails with models.ErrArticleDeleted; only Restore
+// brings it back.
+func saveChunk(ctx context.Context, tx *sqlx.Tx, articles []*models.Article) error {
 	stmt := `
-INSERT INTO articles (id, title, description, url, published_at, source, categories, relevance_score, latitude, longitude, llm_summary)
-VALUES ($1,$2,$3,$4,$5,$6,$7::jsonb,$8,$9,$10,$11)
+INSERT INTO articles (id, title, description, url, published_at, source, categories, relevance_score, base_relevance_score, latitude, longitude, llm_summary, language, content, created_at, updated_at)
+VALUES ($1,$2,$3,$4,$5,$6,$7::jsonb,$8,$8,$9,$10,$11,$12,$13,now(),now())
 ON CONFLICT (id) DO UPDATE SET
  title=EXCLUDED.title,
  description=EXCLUDED.description,
+ content=COALESCE(NULLIF(EXCLUDED.content,''), articles.content),
  url=EXCLUDED.url,
  published_at=EXCLUDED.published_at,
  source=EXCLUDED.source,
  categories=EXCLUDED.categories,
  relevance_score=EXCLUDED.relevance_score,
+ base_relevance_score=EXCLUDED.base_relevance_score,
  latitude=EXCLUDED.latitude,
  longitude=EXCLUDED.longitude,
- llm_summary=EXCLUDED.llm_summary;
+ llm_summary=COALESCE(NULLIF(EXCLUDED.llm_summary,''), articles.llm_summary),
+ summary_model=CASE WHEN EXCLUDED.llm_summary <> '' THEN '' ELSE articles.summary_model END,
+ language=EXCLUDED.language,
+ updated_at=now()
+WHERE articles.deleted_at IS NULL;
 `
 
 	for _, a := range articles {
@@ -81,8 +206,11 @@ ON CONFLICT (id) DO UPDATE SET
 		if a.PublishedAt.IsZero() {
 			a.PublishedAt = time.Now().UTC()
 		}
+		if a.Language == "" {
+			a.Language = lang.Undetermined
+		}
 
-		_, err := tx.Exec(stmt,
+		res, err := tx.ExecContext(ctx, stmt,
 			a.ID,
 			a.Title,
 			a.Description,
@@ -94,71 +222,361 @@ ON CONFLICT (id) DO UPDATE SET
 			a.Latitude,
 			a.Longitude,
 			a.LLMSummary,
+			a.Language,
+			a.Content,
 		)
 		if err != nil {
-			tx.Rollback()
 			return fmt.Errorf("insert article id=%s: %w", a.ID, err)
 		}
+		// the conflict update is skipped (0 rows) only for a soft-deleted row
+		if n, err := res.RowsAffected(); err != nil {
+			return fmt.Errorf("insert article id=%s: %w", a.ID, err)
+		} else if n == 0 {
+			return fmt.Errorf("insert article id=%s: %w", a.ID, models.ErrArticleDeleted)
+		}
 	}
+	return nil
+}
 
-	if err := tx.Commit(); err != nil {
-		return err
+// MaxQueryRows is a safety ceiling on the rows one listing query returns. The
+// per-endpoint limits clients see are enforced by the API (api.DefaultLimits)
+// and never exceed it, so a full page always means more rows may follow.
+const MaxQueryRows = 1000
+
+// rowLimit returns limit, or def when it is non-positive, capped at MaxQueryRows.
+func rowLimit(limit, def int) int {
+	if limit <= 0 {
+		return def
 	}
-	return nil
+	if limit > MaxQueryRows {
+		return MaxQueryRows
+	}
+	return limit
+}
+
+// defaultOrderBy is the listing order used when no sort is requested.
+// Every listing order ends in id so rows with equal keys keep the same order
+// from one page to the next.
+const defaultOrderBy = "relevance_score DESC, published_at DESC, id ASC"
+
+// sortClauses maps the allowed sort orders to fixed ORDER BY clauses,
+// so user input is never interpolated into SQL.
+var sortClauses = map[models.SortOrder]string{
+	models.SortPublishedDesc: "published_at DESC, relevance_score DESC, id ASC",
+	models.SortPublishedAsc:  "published_at ASC, relevance_score DESC, id ASC",
+	models.SortRelevanceDesc: "relevance_score DESC, published_at DESC, id ASC",
+	models.SortTitleAsc:      "title ASC, published_at DESC, id ASC",
+}
+
+// orderBy returns the ORDER BY clause for sort, or fallback for the empty/unknown sort.
+func orderBy(sort models.SortOrder, fallback string) string {
+	if clause, ok := sortClauses[sort]; ok {
+		return clause
+	}
+	return fallback
 }
 
-func (p *PgStore) Search(q string, limit int) ([]*models.Article, error) {
-	if limit <= 0 || limit > 100 {
-		limit = 10
+// articleColumns is the full select list of a live article, in Article field order.
+var articleColumns = []string{"id", "title", "description", "url", "published_at", "source", "categories",
+	"relevance_score", "latitude", "longitude", "llm_summary", "language", "created_at", "updated_at"}
+
+// sparseColumns are the columns a sparse fieldset may pick from: articleColumns
+// plus content and summary_model, which list queries only select when asked for.
+var sparseColumns = append(append([]string{}, articleColumns...), "content", "summary_model")
+
+// selectColumns returns the select list for a sparse fieldset: the requested
+// columns (validated against models.ArticleFields, unknown names are ignored)
+// plus id and any the query needs itself. No fields selects articleColumns.
+func selectColumns(fields []string, required ...string) string {
+	if len(fields) == 0 {
+		return strings.Join(articleColumns, ",")
+	}
+	want := map[string]bool{"id": true}
+	for _, f := range append(fields, required...) {
+		if models.ArticleFields[f] {
+			want[f] = true
+		}
+	}
+	cols := make([]string, 0, len(want))
+	for _, c := range sparseColumns {
+		if want[c] {
+			cols = append(cols, c)
+		}
 	}
-	like := "%%%s%%"
-	like = fmt.Sprintf(like, q)
+	return strings.Join(cols, ",")
+}
+
+// searchRankExpr ranks a row against the plain-text query bound to $1.
+const searchRankExpr = "ts_rank(search_vector, plainto_tsquery('english', $1))"
+
+// escapedDescription is the description with HTML special characters escaped,
+// so highlights can be rendered as HTML with only the <mark> tags live.
+const escapedDescription = "replace(replace(replace(coalesce(description, ''), '&', '&amp;'), '<', '&lt;'), '>', '&gt;')"
+
+// searchHighlightExpr marks the terms of the query bound to $1 in the description.
+const searchHighlightExpr = "ts_headline('english', " + escapedDescription +
+	", plainto_tsquery('english', $1), 'StartSel=<mark>, StopSel=</mark>, MinWords=15, MaxWords=35, MaxFragments=2')"
+
+// Search returns up to limit articles matching q and f, starting after the given cursor
+// (nil means from the beginning) and skipping offset rows (page-number paging; 0 for
+// cursors). The bool result reports whether more rows follow.
+// Matches are ordered by full-text rank multiplied by the source's weight in weights
+// (lower-cased source -> multiplier; unlisted sources weigh 1), then relevance_score and recency.
+// A non-empty sort replaces the rank ordering; the cursor only applies to the default order.
+// With f.Highlight the matched terms are marked in each row's Highlight (see ts_headline).
+func (p *PgStore) Search(ctx context.Context, q string, f models.ArticleFilter, sort models.SortOrder, weights map[string]float64, limit, offset int, after *models.Cursor) ([]*models.Article, bool, error) {
+	where, args := searchWhere(q)
+	return p.rankedSearch(ctx, searchRankExpr, searchHighlightExpr, where, args, f, sort, weights, limit, offset, after)
+}
+
+// fuzzyRankExpr is the trigram similarity of the title to the query bound to $1.
+const fuzzyRankExpr = "similarity(title, $1)"
+
+// fuzzySearchWhere matches titles whose trigram similarity to q ($1) exceeds threshold ($2).
+func fuzzySearchWhere(q string, threshold float64) (string, []interface{}) {
+	return fuzzyRankExpr + " > $2", []interface{}{q, threshold}
+}
+
+// FuzzySearch is the typo-tolerant variant of Search: it matches titles whose
+// pg_trgm similarity to q exceeds threshold (0-1) and ranks by that similarity
+// (reported as search_rank), so "teknology" still finds "technology".
+// Source weighting, paging and sort work as in Search. Trigram matches have no
+// terms to mark, so f.Highlight returns the escaped description as is.
+func (p *PgStore) FuzzySearch(ctx context.Context, q string, threshold float64, f models.ArticleFilter, sort models.SortOrder, weights map[string]float64, limit, offset int, after *models.Cursor) ([]*models.Article, bool, error) {
+	where, args := fuzzySearchWhere(q, threshold)
+	return p.rankedSearch(ctx, fuzzyRankExpr, escapedDescription, where, args, f, sort, weights, limit, offset, after)
+}
+
+// weightedRank returns rankExpr scaled by the weight of each row's source,
+// appending the weight table to args. Without weights it returns rankExpr unchanged.
+// The product is cast back to real so keyset cursors compare at the same precision.
+func weightedRank(rankExpr string, weights map[string]float64, args []interface{}) (string, []interface{}) {
+	if len(weights) == 0 {
+		return rankExpr, args
+	}
+	sources := make([]string, 0, len(weights))
+	values := make([]float64, 0, len(weights))
+	for src, w := range weights {
+		sources = append(sources, src)
+		values = append(values, w)
+	}
+	args = append(args, pq.Array(sources), pq.Array(values))
+	n := len(args)
+	return fmt.Sprintf("(%s * COALESCE((SELECT sw.weight FROM unnest($%d::text[], $%d::float8[]) AS sw(source, weight) WHERE sw.source = lower(articles.source)), 1))::real",
+		rankExpr, n-1, n), args
+}
+
+// searchOrderBy is the default Search order. Like every listing order it ends
+// in id ASC, so ties keep one order from page to page.
+const searchOrderBy = "search_rank DESC, relevance_score DESC, published_at DESC, id ASC"
+
+// searchKeyset returns the keyset condition continuing searchOrderBy after a
+// cursor bound to $n+1..$n+4 (rank, relevance_score, published_at, id). The
+// descending columns compare as one row value; id ascends, so it only breaks
+// ties among rows equal on all three. ts_rank and similarity return real, so
+// the cursor rank is compared as real too.
+func searchKeyset(rankExpr string, n int) string {
+	key := fmt.Sprintf("(%s, relevance_score, published_at)", rankExpr)
+	cur := fmt.Sprintf("($%d::real, $%d, $%d::timestamp)", n+1, n+2, n+3)
+	return fmt.Sprintf("(%s < %s OR (%s = %s AND id > $%d::uuid))", key, cur, key, cur, n+4)
+}
+
+// rankedSearch runs a Search-style query: rows matching where and f, ranked by
+// rankExpr weighted by source (selected as search_rank), then relevance_score and recency.
+// highlightExpr is selected as highlight when f.Highlight is set.
+func (p *PgStore) rankedSearch(ctx context.Context, rankExpr, highlightExpr, where string, args []interface{}, f models.ArticleFilter, sort models.SortOrder, weights map[string]float64, limit, offset int, after *models.Cursor) ([]*models.Article, bool, error) {
+	limit = rowLimit(limit, 10)
+	offset = max(offset, 0)
 	rows := []*models.Article{}
-	query := `
-SELECT id,title,description,url,published_at,source,categories,relevance_score,latitude,longitude,llm_summary
+
+	where, args = applyFilter(where, args, f)
+	rankExpr, args = weightedRank(rankExpr, weights, args)
+	if after != nil {
+		where += " AND " + searchKeyset(rankExpr, len(args))
+		args = append(args, after.Rank, after.Relevance, after.PublishedAt, after.ID)
+	}
+	// fetch one extra row to know whether another page exists
+	args = append(args, limit+1, offset)
+
+	extra := ""
+	if f.Highlight {
+		extra = ",\n  " + highlightExpr + " AS highlight"
+	}
+	// the cursor is built from relevance_score and published_at, so they're always selected
+	query := fmt.Sprintf(`
+SELECT %s,
+  %s AS search_rank%s
 FROM articles
-WHERE title ILIKE $1 OR description ILIKE $1
-ORDER BY relevance_score DESC, published_at DESC
-LIMIT $2
-`
-	err := p.db.Select(&rows, query, like, limit)
-	return rows, err
+WHERE %s
+ORDER BY %s
+LIMIT $%d OFFSET $%d
+`, selectColumns(f.Fields, "relevance_score", "published_at"), rankExpr, extra, where,
+		orderBy(sort, searchOrderBy), len(args)-1, len(args))
+	if err := p.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, false, err
+	}
+	if len(rows) > limit {
+		return rows[:limit], true, nil
+	}
+	return rows, false, nil
+}
+
+// searchWhere builds the WHERE clause (and its args, starting at $1) shared by Search and CountSearch.
+// An empty query matches every article.
+func searchWhere(q string) (string, []interface{}) {
+	return "($1 = '' OR search_vector @@ plainto_tsquery('english', $1))", []interface{}{q}
 }
 
-func (p *PgStore) FindByCategory(category string, limit int) ([]*models.Article, error) {
-	if limit <= 0 || limit > 100 {
-		limit = 10
+// applyFilter appends the conditions of f to where, numbering placeholders after args.
+// Soft-deleted articles are always excluded; empty filter fields add nothing else.
+func applyFilter(where string, args []interface{}, f models.ArticleFilter) (string, []interface{}) {
+	conds := []string{}
+	if where != "" {
+		conds = append(conds, where)
 	}
+	conds = append(conds, "deleted_at IS NULL")
+	if len(f.Sources) > 0 {
+		// case-insensitive match against any of the requested sources
+		lowered := make([]string, len(f.Sources))
+		for i, src := range f.Sources {
+			lowered[i] = strings.ToLower(src)
+		}
+		args = append(args, pq.Array(lowered))
+		conds = append(conds, fmt.Sprintf("lower(source) = ANY($%d::text[])", len(args)))
+	}
+	if f.Language != "" {
+		args = append(args, strings.ToLower(f.Language))
+		conds = append(conds, fmt.Sprintf("language = $%d", len(args)))
+	}
+	if f.MinRelevance != nil {
+		args = append(args, *f.MinRelevance)
+		conds = append(conds, fmt.Sprintf("relevance_score >= $%d", len(args)))
+	}
+	return strings.Join(conds, " AND "), args
+}
+
+// CountSearch returns the total number of articles matching q and f (ignoring paging).
+func (p *PgStore) CountSearch(ctx context.Context, q string, f models.ArticleFilter) (int, error) {
+	where, args := searchWhere(q)
+	where, args = applyFilter(where, args, f)
+	return p.Count(ctx, where, args...)
+}
+
+// CountFuzzySearch returns the total number of FuzzySearch matches (ignoring paging).
+func (p *PgStore) CountFuzzySearch(ctx context.Context, q string, threshold float64, f models.ArticleFilter) (int, error) {
+	where, args := fuzzySearchWhere(q, threshold)
+	where, args = applyFilter(where, args, f)
+	return p.Count(ctx, where, args...)
+}
+
+// Count returns the number of articles matching the given WHERE clause.
+// An empty where counts every article.
+func (p *PgStore) Count(ctx context.Context, where string, args ...interface{}) (int, error) {
+	query := "SELECT COUNT(*) FROM articles"
+	if where != "" {
+		query += " WHERE " + where
+	}
+	var n int
+	err := p.db.GetContext(ctx, &n, query, args...)
+	return n, err
+}
+
+func (p *PgStore) FindByCategory(ctx context.Context, category string, limit int) ([]*models.Article, error) {
+	return p.FindByCategories(ctx, []string{category}, true, models.ArticleFilter{}, "", limit, 0)
+}
+
+// FindByCategories returns articles tagged with all (matchAll) or any of the given categories,
+// skipping the first offset matches.
+func (p *PgStore) FindByCategories(ctx context.Context, categories []string, matchAll bool, f models.ArticleFilter, sort models.SortOrder, limit, offset int) ([]*models.Article, error) {
+	limit = rowLimit(limit, 10)
 	rows := []*models.Article{}
-	// For jsonb array of strings, use @> operator to check containment.
-	// We build a json array with a single element '["category"]' and check categories @> that array.
-	query := `
-SELECT id,title,description,url,published_at,source,categories,relevance_score,latitude,longitude,llm_summary
+	if len(categories) == 0 {
+		return rows, nil
+	}
+
+	where, args := categoryWhere(categories, matchAll)
+	where, args = applyFilter(where, args, f)
+	args = append(args, limit, max(offset, 0))
+	query := fmt.Sprintf(`
+SELECT %s
 FROM articles
-WHERE categories @> ('["' || $1 || '"]')::jsonb
-ORDER BY relevance_score DESC, published_at DESC
-LIMIT $2
-`
-	err := p.db.Select(&rows, query, category, limit)
+WHERE %s
+ORDER BY %s
+LIMIT $%d OFFSET $%d
+`, selectColumns(f.Fields), where, orderBy(sort, defaultOrderBy), len(args)-1, len(args))
+	err := p.db.SelectContext(ctx, &rows, query, args...)
 	return rows, err
 }
 
-func (p *PgStore) All(limit int) ([]*models.Article, error) {
-	if limit <= 0 || limit > 100 {
-		limit = 50
+// categoryWhere builds the WHERE clause (and its $1 arg) shared by FindByCategories and CountByCategories.
+func categoryWhere(categories []string, matchAll bool) (string, []interface{}) {
+	// For jsonb array of strings:
+	//   all -> categories @> '["a","b"]'::jsonb (containment)
+	//   any -> categories ?| array['a','b']     (any top-level element present)
+	if matchAll {
+		return "categories @> $1::jsonb", []interface{}{dbtypes.StringSlice(categories)}
 	}
+	return "categories ?| $1::text[]", []interface{}{pq.Array(categories)}
+}
+
+// CountByCategories returns the total number of articles matching the category filter and f.
+func (p *PgStore) CountByCategories(ctx context.Context, categories []string, matchAll bool, f models.ArticleFilter) (int, error) {
+	if len(categories) == 0 {
+		return 0, nil
+	}
+	where, args := categoryWhere(categories, matchAll)
+	where, args = applyFilter(where, args, f)
+	return p.Count(ctx, where, args...)
+}
+
+// All returns the top articles matching f, ordered by sort (relevance by default).
+func (p *PgStore) All(ctx context.Context, f models.ArticleFilter, sort models.SortOrder, limit int) ([]*models.Article, error) {
+	limit = rowLimit(limit, 50)
 	rows := []*models.Article{}
-	query := `
-SELECT id,title,description,url,published_at,source,categories,relevance_score,latitude,longitude,llm_summary
+	where, args := applyFilter("", nil, f)
+	args = append(args, limit)
+	query := fmt.Sprintf(`
+SELECT %s
 FROM articles
-ORDER BY relevance_score DESC, published_at DESC
-LIMIT $1
-`
-	err := p.db.Select(&rows, query, limit)
+WHERE %s
+ORDER BY %s
+LIMIT $%d
+`, selectColumns(f.Fields), where, orderBy(sort, defaultOrderBy), len(args))
+	err := p.db.SelectContext(ctx, &rows, query, args...)
+	return rows, err
+}
+
+// TrendingWindow returns articles published (or, lacking a date, ingested) in the
+// last windowHours, ranked by ingest-time relevance weighted for recency: the
+// weight halves every windowHours/2, so a fresh story outranks an equally
+// relevant one from the start of the window. Ties go to the newer article.
+func (p *PgStore) TrendingWindow(ctx context.Context, windowHours int, f models.ArticleFilter, limit int) ([]*models.Article, error) {
+	limit = rowLimit(limit, 50)
+	rows := []*models.Article{}
+	where, args := applyFilter("COALESCE(published_at, created_at) >= now() - make_interval(hours => $1)", []interface{}{windowHours}, f)
+	args = append(args, limit)
+	query := fmt.Sprintf(`
+SELECT %s
+FROM articles
+WHERE %s
+ORDER BY COALESCE(base_relevance_score, relevance_score, 0)
+  * power(0.5, GREATEST(0, EXTRACT(EPOCH FROM now() - COALESCE(published_at, created_at)) / 3600) / ($1 / 2.0)) DESC,
+  published_at DESC, id
+LIMIT $%d
+`, selectColumns(f.Fields), where, len(args))
+	err := p.db.SelectContext(ctx, &rows, query, args...)
 	return rows, err
 }
 
-func (p *PgStore) GetByIDs(ids []string) ([]*models.Article, error) {
+// getByIDsChunk is how many ids one GetByIDs query binds; longer lists are
+// split so a single array parameter and result set stay bounded.
+const getByIDsChunk = 1000
+
+// GetByIDs returns the live articles with the given ids in the order requested,
+// including their content.
+// Repeated ids are returned once; lists longer than getByIDsChunk are fetched
+// chunk by chunk and concatenated, which keeps the overall request order.
+func (p *PgStore) GetByIDs(ctx context.Context, ids []string) ([]*models.Article, error) {
 	if len(ids) == 0 {
 		return []*models.Article{}, nil
 	}
@@ -168,63 +586,646 @@ func (p *PgStore) GetByIDs(ids []string) ([]*models.Article, error) {
 	// If only one id was requested, use a simple scalar parameter (avoids array conversion)
 	if len(ids) == 1 {
 		query := `
-SELECT id,title,description,url,published_at,source,categories,relevance_score,latitude,longitude,llm_summary
+SELECT id,title,description,content,url,published_at,source,categories,relevance_score,latitude,longitude,llm_summary,summary_model,language,created_at,updated_at
 FROM articles
-WHERE id = $1
+WHERE id = $1 AND deleted_at IS NULL
 LIMIT 1
 `
-		err := p.db.Select(&rows, query, ids[0])
+		err := p.db.SelectContext(ctx, &rows, query, ids[0])
 		return rows, err
 	}
 
 	// For multiple ids, pass a Postgres array. Cast to uuid[] for UUID columns.
+	// unnest WITH ORDINALITY numbers the requested ids so rows come back in request order.
+	// Deduplicating before chunking keeps a repeated id from appearing in two chunks.
+	ids = uniqueIDs(ids)
+	for start := 0; start < len(ids); start += getByIDsChunk {
+		end := min(start+getByIDsChunk, len(ids))
+		chunk, err := p.getByIDsOrdered(ctx, ids[start:end])
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, chunk...)
+	}
+	return rows, nil
+}
+
+// getByIDsOrdered fetches the live articles among ids (already unique) in the given order.
+func (p *PgStore) getByIDsOrdered(ctx context.Context, ids []string) ([]*models.Article, error) {
+	rows := []*models.Article{}
 	query := `
-SELECT id,title,description,url,published_at,source,categories,relevance_score,latitude,longitude,llm_summary
+SELECT a.id,a.title,a.description,a.content,a.url,a.published_at,a.source,a.categories,a.relevance_score,a.latitude,a.longitude,a.llm_summary,a.summary_model,a.language,a.created_at,a.updated_at
+FROM unnest($1::uuid[]) WITH ORDINALITY AS req(id, ord)
+JOIN articles a ON a.id = req.id
+WHERE a.deleted_at IS NULL
+ORDER BY req.ord
+`
+	// pq.Array encodes the slice as a Postgres array literal so it binds to $1::uuid[].
+	// Ids that don't exist are simply absent from the result.
+	err := p.db.SelectContext(ctx, &rows, query, pq.Array(ids))
+	return rows, err
+}
+
+// uniqueIDs drops repeated ids, keeping the first occurrence of each.
+func uniqueIDs(ids []string) []string {
+	seen := make(map[string]struct{}, len(ids))
+	out := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		out = append(out, id)
+	}
+	return out
+}
+
+// UpdateLLMSummary stores a generated summary and the model that produced it.
+func (p *PgStore) UpdateLLMSummary(ctx context.Context, id, summary, model string) error {
+	_, err := p.db.ExecContext(ctx, "UPDATE articles SET llm_summary = $1, summary_model = $2, updated_at = now() WHERE id = $3", summary, model, id)
+	return err
+}
+
+// Update overwrites the mutable fields of the article with a.ID, bumps
+// updated_at and returns the stored row. It returns sql.ErrNoRows when no
+// article matched.
+// llm_summary is server-generated and left as stored, and an empty a.Content
+// keeps the stored body, so a client omitting them doesn't wipe them.
+func (p *PgStore) Update(ctx context.Context, a *models.Article) (*models.Article, error) {
+	if a.Categories == nil {
+		a.Categories = dbtypes.StringSlice{}
+	}
+	if a.Language == "" {
+		a.Language = lang.Undetermined
+	}
+	query := `
+UPDATE articles SET
+ title=$2,
+ description=$3,
+ url=$4,
+ published_at=$5,
+ source=$6,
+ categories=$7::jsonb,
+ relevance_score=$8,
+ base_relevance_score=$8,
+ latitude=$9,
+ longitude=$10,
+ language=$11,
+ content=COALESCE(NULLIF($12, ''), content),
+ updated_at=now()
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING id,title,description,content,url,published_at,source,categories,relevance_score,latitude,longitude,llm_summary,summary_model,language,created_at,updated_at
+`
+	var out models.Article
+	err := p.db.GetContext(ctx, &out, query,
+		a.ID,
+		a.Title,
+		a.Description,
+		a.URL,
+		a.PublishedAt,
+		a.Source,
+		a.Categories,
+		a.Relevance,
+		a.Latitude,
+		a.Longitude,
+		a.Language,
+		a.Content,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// FindByURLs returns a map of url -> id for the articles that already exist
+// with one of the given urls. Empty urls are ignored.
+func (p *PgStore) FindByURLs(ctx context.Context, urls []string) (map[string]string, error) {
+	out := map[string]string{}
+	nonEmpty := make([]string, 0, len(urls))
+	for _, u := range urls {
+		if u != "" {
+			nonEmpty = append(nonEmpty, u)
+		}
+	}
+	if len(nonEmpty) == 0 {
+		return out, nil
+	}
+
+	rows := []struct {
+		ID  string `db:"id"`
+		URL string `db:"url"`
+	}{}
+	// oldest record wins if duplicates already exist; soft-deleted rows don't
+	// count, so re-ingesting a deleted story creates a fresh record
+	query := `
+SELECT DISTINCT ON (url) id, url
 FROM articles
-WHERE id = ANY($1::uuid[])
+WHERE url = ANY($1::text[]) AND deleted_at IS NULL
+ORDER BY url, created_at ASC
 `
-	// IMPORTANT: use github.com/lib/pq and pass pq.Array(ids)
-	err := p.db.Select(&rows, query, pqArray(ids))
+	if err := p.db.SelectContext(ctx, &rows, query, pq.Array(nonEmpty)); err != nil {
+		return nil, err
+	}
+	for _, r := range rows {
+		out[r.URL] = r.ID
+	}
+	return out, nil
+}
+
+// Sources lists distinct non-empty sources with their article counts, most
+// prolific first. A non-positive limit returns all sources.
+func (p *PgStore) Sources(ctx context.Context, limit int) ([]models.SourceCount, error) {
+	rows := []models.SourceCount{}
+	query := `
+SELECT source, COUNT(*) AS count
+FROM articles
+WHERE source IS NOT NULL AND source <> '' AND deleted_at IS NULL
+GROUP BY source
+ORDER BY COUNT(*) DESC, source ASC
+`
+	args := []interface{}{}
+	if limit > 0 {
+		query += "LIMIT $1\n"
+		args = append(args, limit)
+	}
+	err := p.db.SelectContext(ctx, &rows, query, args...)
+	return rows, err
+}
+
+// DistinctCategories lists the categories in use with their article counts,
+// most frequent first. A non-positive limit returns all categories.
+func (p *PgStore) DistinctCategories(ctx context.Context, limit int) ([]models.CategoryCount, error) {
+	rows := []models.CategoryCount{}
+	query := `
+SELECT c.category, COUNT(*) AS count
+FROM articles,
+  -- guard against non-array jsonb (e.g. 'null'), which jsonb_array_elements_text rejects
+  jsonb_array_elements_text(CASE WHEN jsonb_typeof(articles.categories) = 'array' THEN articles.categories ELSE '[]'::jsonb END) AS c(category)
+WHERE c.category <> '' AND articles.deleted_at IS NULL
+GROUP BY c.category
+ORDER BY COUNT(*) DESC, c.category ASC
+`
+	args := []interface{}{}
+	if limit > 0 {
+		query += "LIMIT $1\n"
+		args = append(args, limit)
+	}
+	err := p.db.SelectContext(ctx, &rows, query, args...)
+	return rows, err
+}
+
+// FindDuplicateURLs lists URLs shared by more than one live article, most
+// duplicated first, with their article ids newest first. Empty URLs are ignored.
+// A non-positive limit returns every duplicate URL.
+func (p *PgStore) FindDuplicateURLs(ctx context.Context, limit int) ([]models.DuplicateURL, error) {
+	rows := []models.DuplicateURL{}
+	query := `
+SELECT url, COUNT(*) AS count, json_agg(id ORDER BY created_at DESC, id) AS ids
+FROM articles
+WHERE deleted_at IS NULL AND COALESCE(url, '') <> ''
+GROUP BY url
+HAVING COUNT(*) > 1
+ORDER BY COUNT(*) DESC, url ASC
+`
+	args := []interface{}{}
+	if limit > 0 {
+		query += "LIMIT $1\n"
+		args = append(args, limit)
+	}
+	err := p.db.SelectContext(ctx, &rows, query, args...)
 	return rows, err
 }
 
-// pqArray helper: sqlx.Select handles pq.Array when using database/sql driver
-// but to avoid adding pq import here we marshal a simple interface that sqlx accepts.
-// We define pqArray as an alias for compatibility; if needed switch to pq.Array(ids).
-func pqArray(a []string) interface{} {
-	return a
+// Stats computes aggregate counts over live articles in a single scan.
+// Last24h is by published_at.
+func (p *PgStore) Stats(ctx context.Context) (*models.Stats, error) {
+	var st models.Stats
+	query := `
+SELECT
+  COUNT(*) AS total,
+  COUNT(*) FILTER (WHERE published_at >= now() - interval '24 hours') AS last_24h,
+  COUNT(*) FILTER (WHERE COALESCE(llm_summary, '') <> '') AS with_summary,
+  COUNT(*) FILTER (WHERE COALESCE(llm_summary, '') = '') AS without_summary,
+  COUNT(*) FILTER (WHERE latitude IS NOT NULL AND longitude IS NOT NULL) AS with_geo,
+  COUNT(DISTINCT lower(source)) FILTER (WHERE COALESCE(source, '') <> '') AS sources,
+  now() AS generated_at
+FROM articles
+WHERE deleted_at IS NULL
+`
+	if err := p.db.GetContext(ctx, &st, query); err != nil {
+		return nil, err
+	}
+	return &st, nil
 }
 
-func (p *PgStore) UpdateLLMSummary(id string, summary string) error {
-	// use ExecContext if you prefer ctx-aware; keep simple for now
-	_, err := p.db.Exec("UPDATE articles SET llm_summary = $1 WHERE id = $2", summary, id)
+// RelevanceBases returns the inputs needed to recompute every article's relevance.
+func (p *PgStore) RelevanceBases(ctx context.Context) ([]models.RelevanceBase, error) {
+	rows := []models.RelevanceBase{}
+	query := `
+SELECT id, COALESCE(base_relevance_score, relevance_score, 0) AS base_relevance_score, published_at
+FROM articles
+WHERE published_at IS NOT NULL AND deleted_at IS NULL
+`
+	err := p.db.SelectContext(ctx, &rows, query)
+	return rows, err
+}
+
+// UpdateRelevanceScores sets relevance_score for many articles in a single statement.
+func (p *PgStore) UpdateRelevanceScores(ctx context.Context, scores map[string]float64) (int64, error) {
+	if len(scores) == 0 {
+		return 0, nil
+	}
+	ids := make([]string, 0, len(scores))
+	vals := make([]float64, 0, len(scores))
+	for id, v := range scores {
+		ids = append(ids, id)
+		vals = append(vals, v)
+	}
+	query := `
+UPDATE articles AS a
+SET relevance_score = v.score
+FROM unnest($1::uuid[], $2::float8[]) AS v(id, score)
+WHERE a.id = v.id
+`
+	res, err := p.db.ExecContext(ctx, query, pq.Array(ids), pq.Array(vals))
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// UpdateEmbedding stores the embedding vector for one article.
+func (p *PgStore) UpdateEmbedding(ctx context.Context, id string, vec []float32) error {
+	_, err := p.db.ExecContext(ctx, "UPDATE articles SET embedding = $1::vector WHERE id = $2", dbtypes.Vector(vec), id)
 	return err
 }
 
-func (p *PgStore) Nearby(lat, lon, radiusKm float64, limit int) ([]*models.Article, error) {
-	if limit <= 0 || limit > 200 {
-		limit = 50
+// semanticOrderBy orders SemanticSearch results, closest first.
+const semanticOrderBy = "similarity DESC, id ASC"
+
+// SemanticSearch returns the articles whose embeddings are closest to vec by cosine distance.
+// Similarity is set to 1 - cosine distance.
+func (p *PgStore) SemanticSearch(ctx context.Context, vec []float32, limit int) ([]*models.Article, error) {
+	limit = rowLimit(limit, 10)
+	rows := []*models.Article{}
+	// the inner ORDER BY must be the bare distance for the HNSW index to serve
+	// it; the outer one adds the id tiebreaker
+	query := `
+SELECT * FROM (
+  SELECT id,title,description,url,published_at,source,categories,relevance_score,latitude,longitude,llm_summary,language,created_at,updated_at,
+    1 - (embedding <=> $1::vector) AS similarity
+  FROM articles
+  WHERE embedding IS NOT NULL AND deleted_at IS NULL
+  ORDER BY embedding <=> $1::vector
+  LIMIT $2
+) AS nearest
+ORDER BY ` + semanticOrderBy + `
+`
+	err := p.db.SelectContext(ctx, &rows, query, dbtypes.Vector(vec), limit)
+	return rows, err
+}
+
+// Delete soft-deletes the article with the given id by setting deleted_at; the row
+// is kept for auditing but hidden from reads. It returns sql.ErrNoRows when no
+// live article matched.
+func (p *PgStore) Delete(ctx context.Context, id string) error {
+	res, err := p.db.ExecContext(ctx, "UPDATE articles SET deleted_at = now(), updated_at = now() WHERE id = $1 AND deleted_at IS NULL", id)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// DeleteMany soft-deletes the articles with the given ids and returns how many
+// were actually deleted; unknown or already-deleted ids are skipped.
+func (p *PgStore) DeleteMany(ctx context.Context, ids []string) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	res, err := p.db.ExecContext(ctx, "UPDATE articles SET deleted_at = now(), updated_at = now() WHERE id = ANY($1::uuid[]) AND deleted_at IS NULL", pq.Array(ids))
+	if err != nil {
+		return 0, err
 	}
+	return res.RowsAffected()
+}
 
-	// Haversine formula computed in subquery to avoid repeating calculation
+// Restore clears deleted_at on a soft-deleted article.
+// It returns sql.ErrNoRows when no soft-deleted article matched.
+func (p *PgStore) Restore(ctx context.Context, id string) error {
+	res, err := p.db.ExecContext(ctx, "UPDATE articles SET deleted_at = NULL, updated_at = now() WHERE id = $1 AND deleted_at IS NOT NULL", id)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// Deleted lists soft-deleted articles, most recently deleted first.
+func (p *PgStore) Deleted(ctx context.Context, limit, offset int) ([]*models.Article, error) {
+	limit = rowLimit(limit, 50)
+	if offset < 0 {
+		offset = 0
+	}
 	query := `
-SELECT id, title, description, url, published_at, source, categories, relevance_score, latitude, longitude, llm_summary, distance_km
+SELECT id,title,description,url,published_at,source,categories,relevance_score,latitude,longitude,llm_summary,language,created_at,updated_at,deleted_at
+FROM articles
+WHERE deleted_at IS NOT NULL
+ORDER BY deleted_at DESC, id
+LIMIT $1 OFFSET $2
+`
+	rows := []*models.Article{}
+	err := p.db.SelectContext(ctx, &rows, query, limit, offset)
+	return rows, err
+}
+
+// ArticlesWithoutSummary returns live articles with no LLM summary, ordered by id.
+// after is the last id of the previous page ("" for the first); keyset paging
+// keeps pages stable while a backfill fills in the summaries it has read.
+func (p *PgStore) ArticlesWithoutSummary(ctx context.Context, after string, limit int) ([]*models.Article, error) {
+	limit = rowLimit(limit, 50)
+	where := "(llm_summary = '' OR llm_summary IS NULL) AND deleted_at IS NULL"
+	args := []interface{}{}
+	if after != "" {
+		args = append(args, after)
+		where += " AND id > $1"
+	}
+	args = append(args, limit)
+	query := fmt.Sprintf(`
+SELECT id,title,description,url,published_at,source,categories,relevance_score,latitude,longitude,llm_summary,language,created_at,updated_at
+FROM articles
+WHERE %s
+ORDER BY id
+LIMIT $%d
+`, where, len(args))
+	rows := []*models.Article{}
+	err := p.db.SelectContext(ctx, &rows, query, args...)
+	return rows, err
+}
+
+// WithoutGeo returns live articles lacking coordinates, ordered by id, for
+// geocoding backfills. after is the last id of the previous page ("" for the
+// first), as in ArticlesWithoutSummary.
+func (p *PgStore) WithoutGeo(ctx context.Context, after string, limit int) ([]*models.Article, error) {
+	limit = rowLimit(limit, 50)
+	where := "(latitude IS NULL OR longitude IS NULL) AND deleted_at IS NULL"
+	args := []interface{}{}
+	if after != "" {
+		args = append(args, after)
+		where += " AND id > $1"
+	}
+	args = append(args, limit)
+	query := fmt.Sprintf(`
+SELECT id,title,description,url,published_at,source,categories,relevance_score,latitude,longitude,llm_summary,language,created_at,updated_at
+FROM articles
+WHERE %s
+ORDER BY id
+LIMIT $%d
+`, where, len(args))
+	rows := []*models.Article{}
+	err := p.db.SelectContext(ctx, &rows, query, args...)
+	return rows, err
+}
+
+// UpdateGeo sets the coordinates of a live article and returns the stored row.
+// It returns sql.ErrNoRows when no article matched.
+func (p *PgStore) UpdateGeo(ctx context.Context, id string, lat, lon float64) (*models.Article, error) {
+	query := `
+UPDATE articles SET latitude = $2, longitude = $3, updated_at = now()
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING id,title,description,url,published_at,source,categories,relevance_score,latitude,longitude,llm_summary,language,created_at,updated_at
+`
+	var out models.Article
+	if err := p.db.GetContext(ctx, &out, query, id, lat, lon); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Nearby returns articles within radiusKm of (lat, lon), closest first, skipping offset rows.
+// fields optionally limits the selected columns (see models.ArticleFilter.Fields).
+func (p *PgStore) Nearby(ctx context.Context, lat, lon, radiusKm float64, limit, offset int, fields []string) ([]*models.Article, error) {
+	limit = rowLimit(limit, 50)
+	if offset < 0 {
+		offset = 0
+	}
+
+	// Haversine formula computed in subquery to avoid repeating calculation.
+	// The acos argument is clamped to [-1, 1]: rounding can push it just past 1
+	// for points at the reference location, which acos rejects.
+	query := `
+SELECT ` + selectColumns(fields) + `, distance_km
 FROM (
   SELECT
-    id, title, description, url, published_at, source, categories, relevance_score, latitude, longitude, llm_summary,
-    (6371 * acos(
+    id, title, description, content, url, published_at, source, categories, relevance_score, latitude, longitude, llm_summary, summary_model, language, created_at, updated_at,
+    (6371 * acos(LEAST(1, GREATEST(-1,
         cos(radians($1)) * cos(radians(latitude)) * cos(radians(longitude) - radians($2)) +
         sin(radians($1)) * sin(radians(latitude))
-    )) AS distance_km
+    )))) AS distance_km
   FROM articles
-  WHERE latitude IS NOT NULL AND longitude IS NOT NULL
+  WHERE latitude IS NOT NULL AND longitude IS NOT NULL AND deleted_at IS NULL
 ) AS t
 WHERE distance_km <= $3
-ORDER BY distance_km ASC
-LIMIT $4;
+ORDER BY distance_km ASC, id ASC
+LIMIT $4 OFFSET $5;
 `
 
 	rows := []*models.Article{}
-	err := p.db.Select(&rows, query, lat, lon, radiusKm, limit)
+	err := p.db.SelectContext(ctx, &rows, query, lat, lon, radiusKm, limit, offset)
+	return rows, unsupported(err)
+}
+
+// unsupported wraps err in models.ErrQueryUnsupported when Postgres rejected
+// the query for a missing function (42883), column (42703), type or other
+// object (42704), or extension library (58P01).
+func unsupported(err error) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return err
+	}
+	switch pqErr.Code {
+	case "42883", "42703", "42704", "58P01":
+		return fmt.Errorf("%w: %v", models.ErrQueryUnsupported, err)
+	}
+	return err
+}
+
+// NearbyPostGIS is Nearby backed by the PostGIS geography column: ST_DWithin
+// uses the GiST index and distances are computed on the spheroid.
+// Requires RunPostGISMigrations.
+func (p *PgStore) NearbyPostGIS(ctx context.Context, lat, lon, radiusKm float64, limit, offset int, fields []string) ([]*models.Article, error) {
+	limit = rowLimit(limit, 50)
+	if offset < 0 {
+		offset = 0
+	}
+
+	query := `
+SELECT ` + selectColumns(fields) + `,
+  ST_Distance(geog, ref.pt) / 1000 AS distance_km
+FROM articles, (SELECT ST_SetSRID(ST_MakePoint($2, $1), 4326)::geography AS pt) AS ref
+WHERE ST_DWithin(geog, ref.pt, $3 * 1000) AND deleted_at IS NULL
+ORDER BY geog <-> ref.pt, id ASC
+LIMIT $4 OFFSET $5;
+`
+
+	rows := []*models.Article{}
+	err := p.db.SelectContext(ctx, &rows, query, lat, lon, radiusKm, limit, offset)
+	return rows, unsupported(err)
+}
+
+// InBoundingBox returns articles whose coordinates fall inside the box, most
+// relevant first. When minLon > maxLon the box crosses the antimeridian and
+// the longitude range is split into [minLon, 180] and [-180, maxLon].
+// fields optionally limits the selected columns; latitude and longitude are always selected.
+func (p *PgStore) InBoundingBox(ctx context.Context, minLat, minLon, maxLat, maxLon float64, limit int, fields []string) ([]*models.Article, error) {
+	limit = rowLimit(limit, 50)
+
+	lonWhere := "longitude BETWEEN $3 AND $4"
+	if minLon > maxLon {
+		lonWhere = "(longitude BETWEEN $3 AND 180 OR longitude BETWEEN -180 AND $4)"
+	}
+	query := `
+SELECT ` + selectColumns(fields, "latitude", "longitude") + `
+FROM articles
+WHERE latitude BETWEEN $1 AND $2 AND ` + lonWhere + ` AND deleted_at IS NULL
+ORDER BY ` + defaultOrderBy + `
+LIMIT $5`
+
+	rows := []*models.Article{}
+	err := p.db.SelectContext(ctx, &rows, query, minLat, maxLat, minLon, maxLon, limit)
+	return rows, err
+}
+
+// ArchiveRange returns articles published within [from, to], oldest first.
+func (p *PgStore) ArchiveRange(ctx context.Context, from, to time.Time, limit, offset int) ([]*models.Article, error) {
+	limit = rowLimit(limit, 50)
+	if offset < 0 {
+		offset = 0
+	}
+	query := `
+SELECT id,title,description,url,published_at,source,categories,relevance_score,latitude,longitude,llm_summary,language,created_at,updated_at
+FROM articles
+WHERE published_at BETWEEN $1 AND $2 AND deleted_at IS NULL
+ORDER BY published_at ASC, id ASC
+LIMIT $3 OFFSET $4
+`
+	rows := []*models.Article{}
+	err := p.db.SelectContext(ctx, &rows, query, from, to, limit, offset)
+	return rows, err
+}
+
+// RecentWithin returns live articles published in the last d, newest first.
+func (p *PgStore) RecentWithin(ctx context.Context, d time.Duration, limit int) ([]*models.Article, error) {
+	limit = rowLimit(limit, 50)
+	query := `
+SELECT id,title,description,url,published_at,source,categories,relevance_score,latitude,longitude,llm_summary,language,created_at,updated_at
+FROM articles
+WHERE published_at >= now() - make_interval(secs => $1) AND deleted_at IS NULL
+ORDER BY published_at DESC, id ASC
+LIMIT $2
+`
+	rows := []*models.Article{}
+	err := p.db.SelectContext(ctx, &rows, query, d.Seconds(), limit)
+	return rows, err
+}
+
+// ExportPage returns the next batch of live articles for an export, ordered by
+// id and starting after the id after ("" for the first batch). Zero from/to
+// leave that end of the published_at range open.
+func (p *PgStore) ExportPage(ctx context.Context, from, to time.Time, after string, limit int) ([]*models.Article, error) {
+	limit = rowLimit(limit, 500)
+	conds := []string{"deleted_at IS NULL"}
+	args := []interface{}{}
+	if !from.IsZero() {
+		args = append(args, from)
+		conds = append(conds, fmt.Sprintf("published_at >= $%d", len(args)))
+	}
+	if !to.IsZero() {
+		args = append(args, to)
+		conds = append(conds, fmt.Sprintf("published_at <= $%d", len(args)))
+	}
+	if after != "" {
+		args = append(args, after)
+		conds = append(conds, fmt.Sprintf("id > $%d", len(args)))
+	}
+	args = append(args, limit)
+	query := fmt.Sprintf(`
+SELECT %s
+FROM articles
+WHERE %s
+ORDER BY id
+LIMIT $%d
+`, strings.Join(articleColumns, ","), strings.Join(conds, " AND "), len(args))
+	rows := []*models.Article{}
+	err := p.db.SelectContext(ctx, &rows, query, args...)
+	return rows, err
+}
+
+// ReindexBatch rewrites the next limit articles (soft-deleted ones included)
+// with id > after, in id order, so Postgres recomputes their generated columns
+// (search_vector, and geog when PostGIS is enabled). Each batch is a single
+// short statement, so row locks are held only briefly. It returns the touched
+// rows in id order with id, title, description and deleted_at set.
+func (p *PgStore) ReindexBatch(ctx context.Context, after string, limit int) ([]*models.Article, error) {
+	limit = rowLimit(limit, 500)
+	where := "TRUE"
+	args := []interface{}{}
+	if after != "" {
+		args = append(args, after)
+		where = "id > $1"
+	}
+	args = append(args, limit)
+	query := fmt.Sprintf(`
+WITH batch AS (
+  SELECT id FROM articles WHERE %s ORDER BY id LIMIT $%d
+), touched AS (
+  UPDATE articles a SET title = a.title
+  FROM batch WHERE a.id = batch.id
+  RETURNING a.id, a.title, a.description, a.deleted_at
+)
+SELECT id, title, description, deleted_at FROM touched ORDER BY id
+`, where, len(args))
+	rows := []*models.Article{}
+	err := p.db.SelectContext(ctx, &rows, query, args...)
+	return rows, err
+}
+
+// similarOrderBy breaks ties between articles sharing as many categories.
+const similarOrderBy = "relevance_score DESC, published_at DESC, id ASC"
+
+// SimilarByCategories returns other articles sharing at least one category with
+// the article id, ordered by the number of shared categories, then relevance.
+// It returns sql.ErrNoRows when the source article doesn't exist.
+func (p *PgStore) SimilarByCategories(ctx context.Context, id string, limit int) ([]*models.Article, error) {
+	limit = rowLimit(limit, 10)
+	var cats dbtypes.StringSlice
+	if err := p.db.GetContext(ctx, &cats, "SELECT categories FROM articles WHERE id = $1 AND deleted_at IS NULL", id); err != nil {
+		return nil, err
+	}
+	rows := []*models.Article{}
+	if len(cats) == 0 {
+		return rows, nil
+	}
+	query := `
+SELECT id,title,description,url,published_at,source,categories,relevance_score,latitude,longitude,llm_summary,language,created_at,updated_at
+FROM articles
+WHERE categories ?| $2::text[] AND id <> $1 AND deleted_at IS NULL
+ORDER BY (SELECT COUNT(*) FROM jsonb_array_elements_text(categories) AS c WHERE c = ANY($2::text[])) DESC,
+  ` + similarOrderBy + `
+LIMIT $3
+`
+	err := p.db.SelectContext(ctx, &rows, query, id, pq.Array([]string(cats)), limit)
 	return rows, err
 }
@@ -0,0 +1,607 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	"github.com/nitesh/news_service/pkg/models"
+)
+
+// testStore returns a PgStore on a freshly migrated, empty articles table.
+// These tests need a disposable Postgres database named by TEST_DATABASE_URL
+// and are skipped when it isn't set.
+func testStore(t *testing.T) *PgStore {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := RunMigrations(db); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if _, err := db.Exec("TRUNCATE articles"); err != nil {
+		t.Fatalf("truncate: %v", err)
+	}
+	return NewPgStore(db)
+}
+
+// seed saves articles built from titles and returns them with their ids set.
+func seed(t *testing.T, p *PgStore, titles ...string) []*models.Article {
+	t.Helper()
+	articles := make([]*models.Article, len(titles))
+	for i, title := range titles {
+		articles[i] = &models.Article{ID: uuid.New().String(), Title: title, URL: "https://example.com/" + title}
+	}
+	if err := p.SaveMany(context.Background(), articles); err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+	return articles
+}
+
+// ids returns the ids of articles, in order.
+func ids(articles []*models.Article) []string {
+	out := make([]string, len(articles))
+	for i, a := range articles {
+		out[i] = a.ID
+	}
+	return out
+}
+
+func equalIDs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestGetByIDs(t *testing.T) {
+	p := testStore(t)
+	a := seed(t, p, "one", "two", "three")
+	missing := uuid.New().String()
+
+	tests := []struct {
+		name string
+		ids  []string
+		want []string
+	}{
+		{"none", nil, []string{}},
+		{"single", []string{a[1].ID}, []string{a[1].ID}},
+		{"single missing", []string{missing}, []string{}},
+		{"several", []string{a[0].ID, a[2].ID}, []string{a[0].ID, a[2].ID}},
+		{"missing ids are skipped", []string{a[0].ID, missing, a[1].ID}, []string{a[0].ID, a[1].ID}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := p.GetByIDs(context.Background(), tt.ids)
+			if err != nil {
+				t.Fatalf("GetByIDs: %v", err)
+			}
+			if !equalIDs(ids(got), tt.want) {
+				t.Errorf("got %v, want %v", ids(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestGetByIDsInvalidUUID(t *testing.T) {
+	p := testStore(t)
+	seed(t, p, "one")
+	if _, err := p.GetByIDs(context.Background(), []string{"not-a-uuid", uuid.New().String()}); err == nil {
+		t.Fatal("expected an error for a malformed id")
+	}
+}
+
+func TestSearchStemming(t *testing.T) {
+	p := testStore(t)
+	// The english dictionary stems "running" and "runs" to "run". It leaves
+	// "runner" alone, so a "runner" title isn't a stemming match.
+	a := seed(t, p, "Marathon runs through the city", "Local elections tonight")
+
+	tests := []struct {
+		name string
+		q    string
+		want []string
+	}{
+		{"inflected query", "running", []string{a[0].ID}},
+		{"exact word", "runs", []string{a[0].ID}},
+		{"no match", "football", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, err := p.Search(context.Background(), tt.q, models.ArticleFilter{}, "", nil, 10, 0, nil)
+			if err != nil {
+				t.Fatalf("Search: %v", err)
+			}
+			if !equalIDs(ids(got), tt.want) {
+				t.Errorf("Search(%q) = %v, want %v", tt.q, ids(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateKeepsServerFields(t *testing.T) {
+	p := testStore(t)
+	ctx := context.Background()
+	a := seed(t, p, "original")[0]
+	if err := p.UpdateLLMSummary(ctx, a.ID, "generated summary", "llama3"); err != nil {
+		t.Fatalf("UpdateLLMSummary: %v", err)
+	}
+	a.Content = "full body"
+	if _, err := p.Update(ctx, a); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		content     string
+		wantContent string
+	}{
+		{"omitted content is kept", "", "full body"},
+		{"new content replaces it", "new body", "new body"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// a PUT body without llm_summary, as clients send it
+			got, err := p.Update(ctx, &models.Article{ID: a.ID, Title: "edited", URL: a.URL, Content: tt.content})
+			if err != nil {
+				t.Fatalf("Update: %v", err)
+			}
+			if got.Title != "edited" {
+				t.Errorf("title = %q, want edited", got.Title)
+			}
+			if got.LLMSummary != "generated summary" {
+				t.Errorf("llm_summary = %q, want it kept", got.LLMSummary)
+			}
+			if got.Content != tt.wantContent {
+				t.Errorf("content = %q, want %q", got.Content, tt.wantContent)
+			}
+		})
+	}
+
+	if _, err := p.Update(ctx, &models.Article{ID: uuid.New().String(), Title: "x"}); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Update(missing) error = %v, want sql.ErrNoRows", err)
+	}
+}
+
+func TestReingestKeepsServerFields(t *testing.T) {
+	p := testStore(t)
+	ctx := context.Background()
+	a := seed(t, p, "original")[0]
+	if err := p.UpdateLLMSummary(ctx, a.ID, "generated summary", "llama3"); err != nil {
+		t.Fatalf("UpdateLLMSummary: %v", err)
+	}
+	a.Content = "full body"
+	if _, err := p.Update(ctx, a); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	// the feed sends the story again; Ingest reuses the id stored for its URL
+	existing, err := p.FindByURLs(ctx, []string{a.URL})
+	if err != nil {
+		t.Fatalf("FindByURLs: %v", err)
+	}
+	again := &models.Article{ID: existing[a.URL], Title: "updated title", URL: a.URL}
+	if err := p.SaveMany(ctx, []*models.Article{again}); err != nil {
+		t.Fatalf("SaveMany: %v", err)
+	}
+
+	rows, err := p.GetByIDs(ctx, []string{a.ID})
+	if err != nil || len(rows) != 1 {
+		t.Fatalf("GetByIDs = %d rows, %v", len(rows), err)
+	}
+	got := rows[0]
+	if got.Title != "updated title" {
+		t.Errorf("title = %q, want the re-ingested one", got.Title)
+	}
+	if got.LLMSummary != "generated summary" {
+		t.Errorf("llm_summary = %q, want it kept", got.LLMSummary)
+	}
+	if got.Content != "full body" {
+		t.Errorf("content = %q, want it kept", got.Content)
+	}
+	if got.SummaryModel != "llama3" {
+		t.Errorf("summary_model = %q, want it kept", got.SummaryModel)
+	}
+
+	// a summary supplied at ingest replaces ours and has no model of ours behind it
+	again.LLMSummary = "publisher summary"
+	if err := p.SaveMany(ctx, []*models.Article{again}); err != nil {
+		t.Fatalf("SaveMany: %v", err)
+	}
+	rows, err = p.GetByIDs(ctx, []string{a.ID})
+	if err != nil || len(rows) != 1 {
+		t.Fatalf("GetByIDs = %d rows, %v", len(rows), err)
+	}
+	if rows[0].LLMSummary != "publisher summary" || rows[0].SummaryModel != "" {
+		t.Errorf("llm_summary, summary_model = %q, %q, want the supplied summary without a model", rows[0].LLMSummary, rows[0].SummaryModel)
+	}
+}
+
+func TestIngestDoesNotRestore(t *testing.T) {
+	p := testStore(t)
+	ctx := context.Background()
+	a := seed(t, p, "original")[0]
+	if err := p.Delete(ctx, a.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	err := p.SaveMany(ctx, []*models.Article{{ID: a.ID, Title: "reposted", URL: a.URL}})
+	if !errors.Is(err, models.ErrArticleDeleted) {
+		t.Fatalf("SaveMany error = %v, want ErrArticleDeleted", err)
+	}
+	deleted, err := p.Deleted(ctx, 10, 0)
+	if err != nil {
+		t.Fatalf("Deleted: %v", err)
+	}
+	if len(deleted) != 1 || deleted[0].ID != a.ID || deleted[0].Title != "original" {
+		t.Errorf("Deleted = %+v, want the original article still deleted", deleted)
+	}
+
+	if err := p.Restore(ctx, a.ID); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if err := p.SaveMany(ctx, []*models.Article{{ID: a.ID, Title: "reposted", URL: a.URL}}); err != nil {
+		t.Errorf("SaveMany after Restore: %v", err)
+	}
+}
+
+func TestUnsupported(t *testing.T) {
+	other := errors.New("connection refused")
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"undefined function", &pq.Error{Code: "42883"}, true},
+		{"undefined column", &pq.Error{Code: "42703"}, true},
+		{"undefined object", &pq.Error{Code: "42704"}, true},
+		{"missing extension library", &pq.Error{Code: "58P01"}, true},
+		{"statement timeout", &pq.Error{Code: "57014"}, false},
+		{"not a pq error", other, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := unsupported(tt.err)
+			if got := errors.Is(err, models.ErrQueryUnsupported); got != tt.want {
+				t.Errorf("unsupported(%v) is ErrQueryUnsupported = %v, want %v", tt.err, got, tt.want)
+			}
+			if !tt.want && err != tt.err {
+				t.Errorf("unsupported(%v) = %v, want the error unchanged", tt.err, err)
+			}
+		})
+	}
+}
+
+// fakeTxStore returns a PgStore whose transactions fail with errs in turn
+// (nil once they run out) without touching a database, and a pointer to the
+// number of transactions attempted.
+func fakeTxStore(chunkSize int, errs ...error) (*PgStore, *int) {
+	calls := 0
+	p := &PgStore{chunkSize: chunkSize}
+	p.runTx = func(ctx context.Context, fn func(tx *sqlx.Tx) error) error {
+		calls++
+		if calls <= len(errs) {
+			return errs[calls-1]
+		}
+		return nil
+	}
+	return p, &calls
+}
+
+func TestSaveManyRetry(t *testing.T) {
+	serialization := &pq.Error{Code: "40001"}
+	deadlock := &pq.Error{Code: "40P01"}
+	uniqueViolation := &pq.Error{Code: "23505"}
+
+	tests := []struct {
+		name      string
+		errs      []error
+		wantErr   error
+		wantCalls int
+	}{
+		{"no conflict", nil, nil, 1},
+		{"serialization failure then success", []error{serialization}, nil, 2},
+		{"deadlock then success", []error{deadlock}, nil, 2},
+		{"mixed retryable errors", []error{deadlock, serialization}, nil, 3},
+		{"gives up after txMaxAttempts", []error{serialization, serialization, serialization, serialization}, serialization, txMaxAttempts},
+		{"non-retryable returns at once", []error{uniqueViolation}, uniqueViolation, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, calls := fakeTxStore(10, tt.errs...)
+			err := p.SaveMany(context.Background(), []*models.Article{{Title: "a"}})
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Errorf("SaveMany error = %v, want %v", err, tt.wantErr)
+			}
+			if *calls != tt.wantCalls {
+				t.Errorf("transactions attempted = %d, want %d", *calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestSaveManyRetryPartial(t *testing.T) {
+	// the first chunk commits; the second keeps conflicting
+	serialization := &pq.Error{Code: "40001"}
+	p, calls := fakeTxStore(1, nil, serialization, serialization, serialization)
+	err := p.SaveMany(context.Background(), []*models.Article{{Title: "a"}, {Title: "b"}})
+	var partial *models.PartialSaveError
+	if !errors.As(err, &partial) || partial.ChunksCommitted != 1 || !errors.Is(err, serialization) {
+		t.Fatalf("SaveMany error = %v, want a PartialSaveError after 1 chunk wrapping 40001", err)
+	}
+	if *calls != 1+txMaxAttempts {
+		t.Errorf("transactions attempted = %d, want %d", *calls, 1+txMaxAttempts)
+	}
+}
+
+func TestSaveManyRetryCanceled(t *testing.T) {
+	p, calls := fakeTxStore(10, &pq.Error{Code: "40001"})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := p.SaveMany(ctx, []*models.Article{{Title: "a"}}); !errors.Is(err, context.Canceled) {
+		t.Errorf("SaveMany error = %v, want context.Canceled", err)
+	}
+	if *calls != 1 {
+		t.Errorf("transactions attempted = %d, want 1", *calls)
+	}
+}
+
+func TestGetByIDsPreservesOrder(t *testing.T) {
+	p := testStore(t)
+	a := ids(seed(t, p, "a", "b", "c", "d", "e"))
+
+	tests := []struct {
+		name string
+		ids  []string
+		want []string
+	}{
+		{"reversed", []string{a[4], a[3], a[2], a[1], a[0]}, []string{a[4], a[3], a[2], a[1], a[0]}},
+		{"shuffled", []string{a[2], a[0], a[4], a[1], a[3]}, []string{a[2], a[0], a[4], a[1], a[3]}},
+		{"repeats keep the first position", []string{a[3], a[1], a[3], a[0]}, []string{a[3], a[1], a[0]}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := p.GetByIDs(context.Background(), tt.ids)
+			if err != nil {
+				t.Fatalf("GetByIDs: %v", err)
+			}
+			if !equalIDs(ids(got), tt.want) {
+				t.Errorf("got %v, want %v", ids(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestUniqueIDs(t *testing.T) {
+	tests := []struct {
+		in, want []string
+	}{
+		{nil, []string{}},
+		{[]string{"a", "b", "c"}, []string{"a", "b", "c"}},
+		{[]string{"b", "a", "b", "c", "a"}, []string{"b", "a", "c"}},
+	}
+	for _, tt := range tests {
+		if got := uniqueIDs(tt.in); !equalIDs(got, tt.want) {
+			t.Errorf("uniqueIDs(%v) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+// TestListingsScan checks the listing queries scan into models.Article without
+// selecting distance_km, which only the nearby queries alias.
+func TestListingsScan(t *testing.T) {
+	p := testStore(t)
+	ctx := context.Background()
+	seed(t, p, "alpha story", "beta story")
+
+	tests := []struct {
+		name string
+		run  func() ([]*models.Article, error)
+	}{
+		{"Search", func() ([]*models.Article, error) {
+			rows, _, err := p.Search(ctx, "story", models.ArticleFilter{}, "", nil, 10, 0, nil)
+			return rows, err
+		}},
+		{"Search with fields", func() ([]*models.Article, error) {
+			rows, _, err := p.Search(ctx, "story", models.ArticleFilter{Fields: []string{"title"}}, "", nil, 10, 0, nil)
+			return rows, err
+		}},
+		{"All", func() ([]*models.Article, error) {
+			return p.All(ctx, models.ArticleFilter{}, "", 10)
+		}},
+		{"All sorted", func() ([]*models.Article, error) {
+			return p.All(ctx, models.ArticleFilter{}, models.SortTitleAsc, 10)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := tt.run()
+			if err != nil {
+				t.Fatalf("scan: %v", err)
+			}
+			if len(rows) != 2 {
+				t.Fatalf("got %d rows, want 2", len(rows))
+			}
+			for _, a := range rows {
+				if a.DistanceKm != nil {
+					t.Errorf("article %s has distance_km %v outside nearby", a.ID, *a.DistanceKm)
+				}
+			}
+		})
+	}
+}
+
+func TestNearbySkipsArticlesWithoutCoordinates(t *testing.T) {
+	p := testStore(t)
+	ctx := context.Background()
+	zero := 0.0
+	located := &models.Article{ID: uuid.New().String(), Title: "gulf of guinea", Latitude: &zero, Longitude: &zero}
+	unlocated := &models.Article{ID: uuid.New().String(), Title: "no coordinates"}
+	if err := p.SaveMany(ctx, []*models.Article{located, unlocated}); err != nil {
+		t.Fatalf("SaveMany: %v", err)
+	}
+
+	got, err := p.Nearby(ctx, 0, 0, 50, 10, 0, nil)
+	if err != nil {
+		t.Fatalf("Nearby: %v", err)
+	}
+	if want := []string{located.ID}; !equalIDs(ids(got), want) {
+		t.Errorf("Nearby = %v, want only the located article %v", ids(got), want)
+	}
+
+	stored, err := p.GetByIDs(ctx, []string{unlocated.ID})
+	if err != nil || len(stored) != 1 {
+		t.Fatalf("GetByIDs = %v, %v", stored, err)
+	}
+	if stored[0].Latitude != nil || stored[0].Longitude != nil {
+		t.Errorf("coordinates = %v,%v, want NULL", stored[0].Latitude, stored[0].Longitude)
+	}
+}
+
+func TestGetByIDsChunked(t *testing.T) {
+	p := testStore(t)
+	const n = 2500 // three chunks of getByIDsChunk
+	titles := make([]string, n)
+	for i := range titles {
+		titles[i] = fmt.Sprintf("article-%d", i)
+	}
+	all := ids(seed(t, p, titles...))
+
+	// request in reverse order, with a repeat across chunk boundaries and a missing id
+	req := make([]string, 0, n+2)
+	for i := n - 1; i >= 0; i-- {
+		req = append(req, all[i])
+	}
+	req = append(req, all[n-1], uuid.New().String())
+	want := req[:n]
+
+	got, err := p.GetByIDs(context.Background(), req)
+	if err != nil {
+		t.Fatalf("GetByIDs: %v", err)
+	}
+	if len(got) != n {
+		t.Fatalf("got %d articles, want %d", len(got), n)
+	}
+	if !equalIDs(ids(got), want) {
+		t.Error("articles are not in request order")
+	}
+}
+
+func TestListingOrdersEndInID(t *testing.T) {
+	orders := map[string]string{"default": defaultOrderBy, "search": searchOrderBy,
+		"semantic": semanticOrderBy, "similar": similarOrderBy}
+	for sort, clause := range sortClauses {
+		orders[string(sort)] = clause
+	}
+	for name, clause := range orders {
+		if !strings.HasSuffix(clause, ", id ASC") {
+			t.Errorf("%s order %q does not end in id ASC", name, clause)
+		}
+	}
+}
+
+func TestSearchKeyset(t *testing.T) {
+	got := searchKeyset("rank", 2)
+	want := "((rank, relevance_score, published_at) < ($3::real, $4, $5::timestamp) OR " +
+		"((rank, relevance_score, published_at) = ($3::real, $4, $5::timestamp) AND id > $6::uuid))"
+	if got != want {
+		t.Errorf("searchKeyset =\n%s\nwant\n%s", got, want)
+	}
+}
+
+// TestSearchStablePaging pages through articles that tie on every sort key
+// and checks each one is returned exactly once, in id order.
+func TestSearchStablePaging(t *testing.T) {
+	p := testStore(t)
+	ctx := context.Background()
+	published := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	articles := make([]*models.Article, 7)
+	for i := range articles {
+		articles[i] = &models.Article{ID: uuid.New().String(), Title: "same story", PublishedAt: published, Relevance: 1}
+	}
+	if err := p.SaveMany(ctx, articles); err != nil {
+		t.Fatalf("SaveMany: %v", err)
+	}
+	want := ids(articles)
+	sort.Strings(want)
+
+	t.Run("cursor", func(t *testing.T) {
+		var got []string
+		var after *models.Cursor
+		for page := 0; page < 10; page++ {
+			rows, more, err := p.Search(ctx, "story", models.ArticleFilter{}, "", nil, 3, 0, after)
+			if err != nil {
+				t.Fatalf("Search: %v", err)
+			}
+			got = append(got, ids(rows)...)
+			if !more {
+				break
+			}
+			last := rows[len(rows)-1]
+			after = &models.Cursor{Rank: last.SearchRank, Relevance: last.Relevance, PublishedAt: last.PublishedAt, ID: last.ID}
+		}
+		if !equalIDs(got, want) {
+			t.Errorf("cursor pages = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("offset", func(t *testing.T) {
+		var got []string
+		for offset := 0; offset < len(want); offset += 3 {
+			rows, _, err := p.Search(ctx, "story", models.ArticleFilter{}, "", nil, 3, offset, nil)
+			if err != nil {
+				t.Fatalf("Search: %v", err)
+			}
+			got = append(got, ids(rows)...)
+		}
+		if !equalIDs(got, want) {
+			t.Errorf("offset pages = %v, want %v", got, want)
+		}
+	})
+}
+
+func TestSimilarTiesInIDOrder(t *testing.T) {
+	p := testStore(t)
+	ctx := context.Background()
+	published := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	articles := make([]*models.Article, 6)
+	for i := range articles {
+		articles[i] = &models.Article{ID: uuid.New().String(), Title: "same story", PublishedAt: published,
+			Relevance: 1, Categories: []string{"sports"}}
+	}
+	if err := p.SaveMany(ctx, articles); err != nil {
+		t.Fatalf("SaveMany: %v", err)
+	}
+	want := ids(articles[1:])
+	sort.Strings(want)
+
+	got, err := p.SimilarByCategories(ctx, articles[0].ID, 10)
+	if err != nil {
+		t.Fatalf("SimilarByCategories: %v", err)
+	}
+	if !equalIDs(ids(got), want) {
+		t.Errorf("SimilarByCategories = %v, want %v", ids(got), want)
+	}
+}
@@ -0,0 +1,169 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// SignatureHeader carries the hex HMAC-SHA256 of the request body, keyed with
+// the shared secret, as "sha256=<hex>".
+const SignatureHeader = "X-Webhook-Signature"
+
+// EventHeader names the event a delivery is about.
+const EventHeader = "X-Webhook-Event"
+
+// queueSize bounds pending deliveries; beyond it new events are dropped (and logged).
+const queueSize = 256
+
+// maxAttempts is how many times a delivery to one URL is tried.
+const maxAttempts = 3
+
+// retryBackoff is the base delay between attempts (multiplied by the attempt
+// number). A variable so tests can shorten it.
+var retryBackoff = time.Second
+
+// IngestEvent is the payload posted after a successful ingest.
+type IngestEvent struct {
+	Event     string    `json:"event"`
+	Count     int       `json:"count"`
+	IDs       []string  `json:"ids"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Notifier posts ingest events to a fixed set of URLs in the background, so a
+// slow or failing receiver never holds up the ingest response. Each delivery
+// is retried on errors and non-2xx responses.
+type Notifier struct {
+	urls    []string
+	secret  []byte
+	hc      *http.Client
+	queue   chan []byte
+	wg      sync.WaitGroup
+	closeMu sync.Mutex
+	closed  bool
+}
+
+// New starts a notifier posting to urls with a per-attempt timeout. A non-empty
+// secret signs every body (see SignatureHeader). Call Close on shutdown to
+// deliver what's still queued.
+func New(urls []string, secret string, timeout time.Duration) *Notifier {
+	n := &Notifier{
+		urls:   urls,
+		secret: []byte(secret),
+		hc:     &http.Client{Timeout: timeout},
+		queue:  make(chan []byte, queueSize),
+	}
+	n.wg.Add(1)
+	go n.run()
+	return n
+}
+
+// NotifyIngest queues an ingest event for ids. It never blocks.
+func (n *Notifier) NotifyIngest(ids []string) {
+	body, err := json.Marshal(IngestEvent{Event: "ingest", Count: len(ids), IDs: ids, Timestamp: time.Now().UTC()})
+	if err != nil {
+		log.Printf("webhook: encode event: %v", err)
+		return
+	}
+	n.closeMu.Lock()
+	defer n.closeMu.Unlock()
+	if n.closed {
+		return
+	}
+	select {
+	case n.queue <- body:
+	default:
+		log.Printf("warning: webhook queue full, dropping ingest event for %d articles", len(ids))
+	}
+}
+
+// Close stops accepting events and waits for queued ones to be delivered, or
+// until ctx is done, in which case the rest are abandoned and ctx.Err() returned.
+func (n *Notifier) Close(ctx context.Context) error {
+	n.closeMu.Lock()
+	if !n.closed {
+		n.closed = true
+		close(n.queue)
+	}
+	n.closeMu.Unlock()
+	done := make(chan struct{})
+	go func() {
+		n.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (n *Notifier) run() {
+	defer n.wg.Done()
+	for body := range n.queue {
+		var wg sync.WaitGroup
+		for _, url := range n.urls {
+			wg.Add(1)
+			go func(url string) {
+				defer wg.Done()
+				n.deliver(url, body)
+			}(url)
+		}
+		wg.Wait()
+	}
+}
+
+// deliver posts body to url, retrying up to maxAttempts times.
+func (n *Notifier) deliver(url string, body []byte) {
+	var err error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		if err = n.post(url, body); err == nil {
+			return
+		}
+		if attempt < maxAttempts {
+			time.Sleep(time.Duration(attempt) * retryBackoff)
+		}
+	}
+	log.Printf("webhook %s: giving up after %d attempts: %v", url, maxAttempts, err)
+}
+
+func (n *Notifier) post(url string, body []byte) error {
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, "ingest")
+	if len(n.secret) > 0 {
+		req.Header.Set(SignatureHeader, "sha256="+Sign(n.secret, body))
+	}
+	resp, err := n.hc.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Sign returns the hex HMAC-SHA256 of body keyed with secret, as sent in
+// SignatureHeader; receivers recompute it to verify a delivery.
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
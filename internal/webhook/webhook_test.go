@@ -0,0 +1,133 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSign(t *testing.T) {
+	tests := []struct {
+		secret, body string
+		want         string
+	}{
+		// RFC 4231 test case 2
+		{"Jefe", "what do ya want for nothing?", "5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843"},
+		{"secret", "", "f9e66e179b6747ae54108f82f8ade8b3c25d76fd30afde6c395822c530196169"},
+	}
+	for _, tt := range tests {
+		if got := Sign([]byte(tt.secret), []byte(tt.body)); got != tt.want {
+			t.Errorf("Sign(%q, %q) = %s, want %s", tt.secret, tt.body, got, tt.want)
+		}
+	}
+}
+
+// receiver is a webhook endpoint answering with statuses in turn (200 once
+// they run out) and recording each delivery.
+type receiver struct {
+	mu       sync.Mutex
+	statuses []int
+	headers  []http.Header
+	bodies   [][]byte
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.headers = append(rc.headers, r.Header.Clone())
+	rc.bodies = append(rc.bodies, body)
+	status := http.StatusOK
+	if n := len(rc.bodies); n <= len(rc.statuses) {
+		status = rc.statuses[n-1]
+	}
+	w.WriteHeader(status)
+}
+
+// notify sends one ingest event to rc and waits for its delivery to finish.
+func notify(t *testing.T, rc *receiver, secret string) {
+	t.Helper()
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+	n := New([]string{srv.URL}, secret, time.Second)
+	n.NotifyIngest([]string{"a", "b"})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := n.Close(ctx); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	n.NotifyIngest([]string{"c"}) // dropped after Close, without panicking
+}
+
+func TestDelivery(t *testing.T) {
+	tests := []struct {
+		name    string
+		secret  string
+		wantSig bool
+	}{
+		{"signed", "s3cret", true},
+		{"no secret", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rc := &receiver{}
+			notify(t, rc, tt.secret)
+			if len(rc.bodies) != 1 {
+				t.Fatalf("got %d deliveries, want 1", len(rc.bodies))
+			}
+			var ev IngestEvent
+			if err := json.Unmarshal(rc.bodies[0], &ev); err != nil {
+				t.Fatalf("decode body %s: %v", rc.bodies[0], err)
+			}
+			if ev.Event != "ingest" || ev.Count != 2 || len(ev.IDs) != 2 || ev.Timestamp.IsZero() {
+				t.Errorf("event = %+v", ev)
+			}
+			h := rc.headers[0]
+			if h.Get(EventHeader) != "ingest" || h.Get("Content-Type") != "application/json" {
+				t.Errorf("headers = %v", h)
+			}
+			sig := h.Get(SignatureHeader)
+			if want := "sha256=" + Sign([]byte(tt.secret), rc.bodies[0]); tt.wantSig && sig != want {
+				t.Errorf("%s = %q, want %q", SignatureHeader, sig, want)
+			}
+			if !tt.wantSig && sig != "" {
+				t.Errorf("%s = %q without a secret", SignatureHeader, sig)
+			}
+		})
+	}
+}
+
+func TestDeliveryRetries(t *testing.T) {
+	defer func(d time.Duration) { retryBackoff = d }(retryBackoff)
+	retryBackoff = time.Millisecond
+
+	tests := []struct {
+		name      string
+		statuses  []int
+		wantCalls int
+	}{
+		{"first attempt succeeds", nil, 1},
+		{"retried until it succeeds", []int{http.StatusInternalServerError, http.StatusBadGateway}, 3},
+		{"non-2xx is a failure", []int{http.StatusMovedPermanently}, 2},
+		{"gives up after max attempts", []int{500, 500, 500, 500}, maxAttempts},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rc := &receiver{statuses: tt.statuses}
+			notify(t, rc, "s3cret")
+			if len(rc.bodies) != tt.wantCalls {
+				t.Errorf("got %d attempts, want %d", len(rc.bodies), tt.wantCalls)
+			}
+			for i, b := range rc.bodies[1:] {
+				if string(b) != string(rc.bodies[0]) {
+					t.Errorf("attempt %d body = %s, want the original %s", i+2, b, rc.bodies[0])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS idx_articles_url ON articles(url);
@@ -0,0 +1,2 @@
+ALTER TABLE articles ADD COLUMN IF NOT EXISTS base_relevance_score DOUBLE PRECISION;
+UPDATE articles SET base_relevance_score = relevance_score WHERE base_relevance_score IS NULL;
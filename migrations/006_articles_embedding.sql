@@ -0,0 +1,4 @@
+-- optional: only applied when EMBEDDINGS_ENABLED=true (requires pgvector >= 0.5.0)
+CREATE EXTENSION IF NOT EXISTS vector;
+ALTER TABLE articles ADD COLUMN IF NOT EXISTS embedding vector(768);
+CREATE INDEX IF NOT EXISTS idx_articles_embedding ON articles USING hnsw (embedding vector_cosine_ops);
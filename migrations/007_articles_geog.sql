@@ -0,0 +1,8 @@
+-- optional: only applied when GEO_BACKEND=postgis (requires the postgis extension)
+CREATE EXTENSION IF NOT EXISTS postgis;
+ALTER TABLE articles ADD COLUMN IF NOT EXISTS geog geography(Point, 4326)
+  GENERATED ALWAYS AS (
+    CASE WHEN latitude IS NOT NULL AND longitude IS NOT NULL
+      THEN ST_SetSRID(ST_MakePoint(longitude, latitude), 4326)::geography
+    END) STORED;
+CREATE INDEX IF NOT EXISTS idx_articles_geog ON articles USING GIST (geog);
@@ -0,0 +1,3 @@
+-- soft delete: rows with deleted_at set are hidden from every read query
+ALTER TABLE articles ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP NULL;
+CREATE INDEX IF NOT EXISTS idx_articles_deleted_at ON articles(deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,3 @@
+-- ISO 639 language code detected (or supplied) at ingest; 'und' when undetermined
+ALTER TABLE articles ADD COLUMN IF NOT EXISTS language TEXT NOT NULL DEFAULT 'und';
+CREATE INDEX IF NOT EXISTS idx_articles_language ON articles(language);
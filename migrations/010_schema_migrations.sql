@@ -0,0 +1,6 @@
+-- applied migration versions; RunMigrations and POST /v1/admin/migrate run the pending ones
+CREATE TABLE IF NOT EXISTS schema_migrations(
+  version INT PRIMARY KEY,
+  name TEXT NOT NULL,
+  applied_at TIMESTAMP NOT NULL DEFAULT now()
+);
@@ -0,0 +1,3 @@
+-- trigram index for fuzzy (typo-tolerant) title search; pg_trgm ships with Postgres contrib
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE INDEX IF NOT EXISTS idx_articles_title_trgm ON articles USING GIN (title gin_trgm_ops);
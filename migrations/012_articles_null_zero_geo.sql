@@ -0,0 +1,2 @@
+-- articles ingested without coordinates used to be stored as 0,0; make them unset
+UPDATE articles SET latitude = NULL, longitude = NULL WHERE latitude = 0 AND longitude = 0;
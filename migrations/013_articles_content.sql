@@ -0,0 +1,2 @@
+-- full article body; summaries prefer it over the description
+ALTER TABLE articles ADD COLUMN IF NOT EXISTS content TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,3 @@
+-- model that generated llm_summary (the fallback model when the requested one failed);
+-- empty for summaries supplied at ingest or stored before it was recorded
+ALTER TABLE articles ADD COLUMN IF NOT EXISTS summary_model TEXT NOT NULL DEFAULT '';
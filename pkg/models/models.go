@@ -1,6 +1,13 @@
 package models
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
 	dbtypes "github.com/nitesh/news_service/internal/db"
@@ -11,15 +18,286 @@ type Article struct {
 	ID          string           `db:"id" json:"id"`
 	Title       string           `db:"title" json:"title"`
 	Description string           `db:"description" json:"description"`
+	// Content is the full article body, when the publisher supplied it. It's only
+	// loaded for single articles or when requested via ?fields=, so it's omitted
+	// from list responses by default.
+	Content     string           `db:"content" json:"content,omitempty"`
 	URL         string           `db:"url" json:"url"`
 	PublishedAt time.Time        `db:"published_at" json:"published_at"`
 	Source      string           `db:"source" json:"source"`
 	Categories  dbtypes.StringSlice `db:"categories" json:"categories"`
 	Relevance   float64          `db:"relevance_score" json:"relevance_score"`
-	Latitude    float64          `db:"latitude" json:"latitude"`
-	Longitude   float64          `db:"longitude" json:"longitude"`
+	// Latitude and Longitude are nil (JSON null) when the article has no coordinates,
+	// so an unset location is distinct from a genuine 0,0.
+	Latitude    *float64         `db:"latitude" json:"latitude"`
+	Longitude   *float64         `db:"longitude" json:"longitude"`
 	LLMSummary  string           `db:"llm_summary" json:"llm_summary"`
+	// SummaryModel is the LLM model that generated LLMSummary (empty when unknown,
+	// e.g. a summary supplied at ingest). Like Content it's only loaded for single
+	// articles or when requested via ?fields=.
+	SummaryModel string          `db:"summary_model" json:"summary_model,omitempty"`
+	// Language is an ISO 639 code ("und" when undetermined); detected at ingest unless supplied.
+	Language    string           `db:"language" json:"language"`
+	CreatedAt   time.Time        `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time        `db:"updated_at" json:"updated_at"`
+	// DeletedAt is set when the article has been soft-deleted.
+	DeletedAt   *time.Time       `db:"deleted_at" json:"deleted_at,omitempty"`
 
-	// DistanceKm is set at runtime by the Nearby function (not persisted).
-	DistanceKm  float64          `db:"distance_km" json:"distance_km,omitempty"`
-}
\ No newline at end of file
+	// DistanceKm is set at runtime by the Nearby queries, which alias a distance_km
+	// column (not persisted). It's nil elsewhere, so other endpoints omit it while
+	// nearby responses keep a distance of 0.
+	DistanceKm  *float64         `db:"distance_km" json:"distance_km,omitempty"`
+	// DistanceMi replaces DistanceKm in responses for clients asking for miles (not persisted).
+	DistanceMi  *float64         `db:"-" json:"distance_mi,omitempty"`
+
+	// SearchRank is the full-text rank set at runtime by Search (not persisted).
+	SearchRank  float64          `db:"search_rank" json:"search_rank,omitempty"`
+
+	// Highlight is the HTML-escaped description with the matched terms wrapped
+	// in <mark>, set at runtime by Search when ArticleFilter.Highlight is set (not persisted).
+	Highlight   string           `db:"highlight" json:"highlight,omitempty"`
+
+	// Similarity is the embedding cosine similarity set at runtime by SemanticSearch (not persisted).
+	Similarity  float64          `db:"similarity" json:"similarity,omitempty"`
+}
+
+// publishedAtLayouts are the published_at formats accepted on input, tried in
+// order: RFC 3339 plus the RFC 822/1123 variants RSS feeds send. Unix epoch
+// seconds are accepted too (see ParsePublishedAt).
+var publishedAtLayouts = []string{
+	time.RFC3339Nano,
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	time.RFC822Z,
+	time.RFC822,
+	time.RFC850,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// AddPublishedAtLayouts registers extra Go time layouts for ParsePublishedAt,
+// tried after the built-in ones. Call at startup, before serving requests.
+func AddPublishedAtLayouts(layouts ...string) {
+	for _, l := range layouts {
+		if l = strings.TrimSpace(l); l != "" {
+			publishedAtLayouts = append(publishedAtLayouts, l)
+		}
+	}
+}
+
+// epochPattern matches Unix epoch seconds: digits with an optional fraction
+// (no sign, exponent, NaN or Inf).
+var epochPattern = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?$`)
+
+// Epoch seconds are only accepted between minEpochSeconds (1973-03-03) and
+// maxEpochSeconds (the end of 9999), so a bare year like "2024" or a
+// millisecond timestamp is rejected rather than misread.
+const (
+	minEpochSeconds = 1e8
+	maxEpochSeconds = 253402300799
+)
+
+// ParsePublishedAt parses a published_at value in any supported layout, or as
+// Unix epoch seconds, returning it in UTC. Layouts without a zone are read as
+// UTC. Layouts are tried first; the value is read as epoch seconds only when
+// none matches and it is a plain decimal within the accepted range.
+// An empty value returns the zero time (ingest then defaults it to now).
+func ParsePublishedAt(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, nil
+	}
+	for _, layout := range publishedAtLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	if epochPattern.MatchString(s) {
+		secs, err := strconv.ParseFloat(s, 64)
+		if err == nil && secs >= minEpochSeconds && secs <= maxEpochSeconds {
+			sec := int64(secs)
+			return time.Unix(sec, int64((secs-float64(sec))*1e9)).UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("published_at: unsupported time %q (use RFC 3339, RFC 1123 or Unix epoch seconds)", s)
+}
+
+// UnmarshalJSON decodes an article, accepting published_at in any format
+// ParsePublishedAt supports (a string or a number of epoch seconds). A missing,
+// null or empty published_at leaves the zero time.
+func (a *Article) UnmarshalJSON(b []byte) error {
+	type plain Article // no methods, so no recursion
+	aux := struct {
+		*plain
+		PublishedAt json.RawMessage `json:"published_at"`
+	}{plain: (*plain)(a)}
+	if err := json.Unmarshal(b, &aux); err != nil {
+		return err
+	}
+	raw := bytes.TrimSpace(aux.PublishedAt)
+	a.PublishedAt = time.Time{}
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return nil
+	}
+	var s string
+	if raw[0] == '"' {
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return fmt.Errorf("published_at: %w", err)
+		}
+	} else {
+		s = string(raw)
+	}
+	t, err := ParsePublishedAt(s)
+	if err != nil {
+		return err
+	}
+	a.PublishedAt = t
+	return nil
+}
+
+// RelevanceBase is the data needed to recompute an article's time-decayed relevance.
+type RelevanceBase struct {
+	ID          string    `db:"id"`
+	BaseScore   float64   `db:"base_relevance_score"`
+	PublishedAt time.Time `db:"published_at"`
+}
+
+// SourceCount is the number of articles stored for one source.
+type SourceCount struct {
+	Source string `db:"source" json:"source"`
+	Count  int    `db:"count" json:"count"`
+}
+
+// CategoryCount is the number of articles tagged with one category.
+type CategoryCount struct {
+	Category string `db:"category" json:"category"`
+	Count    int    `db:"count" json:"count"`
+}
+
+// DuplicateURL is a URL shared by more than one live article. IDs are ordered
+// newest first (by created_at), so IDs[1:] are the older copies to delete.
+type DuplicateURL struct {
+	URL   string              `db:"url" json:"url"`
+	Count int                 `db:"count" json:"count"`
+	IDs   dbtypes.StringSlice `db:"ids" json:"ids"`
+}
+
+// Stats holds aggregate counts over live (not soft-deleted) articles.
+type Stats struct {
+	Total          int       `db:"total" json:"total"`
+	Last24h        int       `db:"last_24h" json:"last_24h"`
+	WithSummary    int       `db:"with_summary" json:"with_summary"`
+	// WithoutSummary counts articles still needing a summary (see /v1/news/unsummarized).
+	WithoutSummary int       `db:"without_summary" json:"without_summary"`
+	WithGeo        int       `db:"with_geo" json:"with_geo"`
+	Sources        int       `db:"sources" json:"sources"`
+	GeneratedAt    time.Time `db:"generated_at" json:"generated_at"`
+}
+
+// Migration is a schema migration; AppliedAt is nil while it's pending.
+type Migration struct {
+	Version   int        `db:"version" json:"version"`
+	Name      string     `db:"name" json:"name"`
+	AppliedAt *time.Time `db:"applied_at" json:"applied_at,omitempty"`
+}
+
+// SchemaStatus reports the applied and pending core migrations. Version is
+// the highest applied version. Optional migrations (pgvector, PostGIS) are
+// listed once applied but never as pending.
+type SchemaStatus struct {
+	Version int         `json:"version"`
+	Applied []Migration `json:"applied"`
+	Pending []Migration `json:"pending"`
+}
+
+// Cursor marks the position after which a keyset-paginated query continues.
+// It mirrors the ORDER BY columns (search rank, relevance_score, published_at, id).
+type Cursor struct {
+	Rank        float64
+	Relevance   float64
+	PublishedAt time.Time
+	ID          string
+}
+
+// PartialSaveError is returned by a chunked save that failed after some chunks
+// had already been committed. Articles in committed chunks stay saved.
+type PartialSaveError struct {
+	ChunksCommitted int
+	ChunksTotal     int
+	Saved           int
+	Err             error
+}
+
+func (e *PartialSaveError) Error() string {
+	return fmt.Sprintf("saved %d articles in %d of %d chunks: %v", e.Saved, e.ChunksCommitted, e.ChunksTotal, e.Err)
+}
+
+func (e *PartialSaveError) Unwrap() error { return e.Err }
+
+// ErrQueryUnsupported is returned (wrapped) by a store query that needs a
+// function, extension or column the database doesn't have, e.g. PostGIS.
+var ErrQueryUnsupported = errors.New("query not supported by the database")
+
+// ErrArticleDeleted is returned (wrapped) when an ingest posts the id of a
+// soft-deleted article; it has to be restored first.
+var ErrArticleDeleted = errors.New("article is deleted")
+
+// ArticleFilter holds optional filters shared by the listing endpoints.
+// Zero-valued fields apply no filtering.
+type ArticleFilter struct {
+	// Sources restricts results to these sources (case-insensitive, any of).
+	Sources []string
+	// Language restricts results to one ISO 639 language code.
+	Language string
+	// MinRelevance, when set, drops articles whose relevance_score is below it.
+	MinRelevance *float64
+	// Fields limits which ArticleFields are selected (sparse fieldsets); nil selects all.
+	Fields []string
+	// Highlight makes Search set Article.Highlight (ignored by other listings).
+	Highlight bool
+}
+
+// ArticleFields lists the persisted Article fields that can be requested with
+// ?fields=. The JSON names double as the column names.
+var ArticleFields = map[string]bool{
+	"id":              true,
+	"title":           true,
+	"description":     true,
+	"content":         true,
+	"url":             true,
+	"published_at":    true,
+	"source":          true,
+	"categories":      true,
+	"relevance_score": true,
+	"latitude":        true,
+	"longitude":       true,
+	"llm_summary":     true,
+	"summary_model":   true,
+	"language":        true,
+	"created_at":      true,
+	"updated_at":      true,
+}
+
+// SortOrder names an allowed ordering for listing endpoints.
+// The empty value means "use the endpoint's default ordering".
+type SortOrder string
+
+const (
+	SortPublishedDesc SortOrder = "published_desc"
+	SortPublishedAsc  SortOrder = "published_asc"
+	SortRelevanceDesc SortOrder = "relevance_desc"
+	SortTitleAsc      SortOrder = "title_asc"
+)
+
+// Valid reports whether s is empty or one of the known sort orders.
+func (s SortOrder) Valid() bool {
+	switch s {
+	case "", SortPublishedDesc, SortPublishedAsc, SortRelevanceDesc, SortTitleAsc:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,157 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestArticleDistanceJSON(t *testing.T) {
+	zero, km := 0.0, 3.5
+	tests := []struct {
+		name     string
+		distance *float64
+		want     string // "" means the field must be absent
+	}{
+		{"unset on non-nearby endpoints", nil, ""},
+		{"zero distance is kept", &zero, `"distance_km":0`},
+		{"distance", &km, `"distance_km":3.5`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := json.Marshal(&Article{ID: "x", DistanceKm: tt.distance})
+			if err != nil {
+				t.Fatalf("Marshal: %v", err)
+			}
+			has := strings.Contains(string(b), `"distance_km"`)
+			if tt.want == "" && has {
+				t.Errorf("distance_km present in %s", b)
+			}
+			if tt.want != "" && !strings.Contains(string(b), tt.want) {
+				t.Errorf("%s does not contain %s", b, tt.want)
+			}
+		})
+	}
+}
+
+func TestArticleCoordinatesJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		wantNil bool
+		want    string
+	}{
+		{"missing", `{"id":"x"}`, true, `"latitude":null,"longitude":null`},
+		{"null", `{"id":"x","latitude":null,"longitude":null}`, true, `"latitude":null,"longitude":null`},
+		{"zero is a real location", `{"id":"x","latitude":0,"longitude":0}`, false, `"latitude":0,"longitude":0`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var a Article
+			if err := json.Unmarshal([]byte(tt.in), &a); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			if (a.Latitude == nil) != tt.wantNil || (a.Longitude == nil) != tt.wantNil {
+				t.Errorf("latitude=%v longitude=%v, want nil = %v", a.Latitude, a.Longitude, tt.wantNil)
+			}
+			b, err := json.Marshal(&a)
+			if err != nil {
+				t.Fatalf("Marshal: %v", err)
+			}
+			if !strings.Contains(string(b), tt.want) {
+				t.Errorf("%s does not contain %s", b, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePublishedAt(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string // RFC 3339; "" for the zero time
+		wantErr bool
+	}{
+		{"", "", false},
+		{"  ", "", false},
+		{"2024-01-02T03:04:05Z", "2024-01-02T03:04:05Z", false},
+		{"2024-01-02T08:34:05+05:30", "2024-01-02T03:04:05Z", false},
+		{"Tue, 02 Jan 2024 03:04:05 +0000", "2024-01-02T03:04:05Z", false},
+		{"2024-01-02 03:04:05", "2024-01-02T03:04:05Z", false},
+		{"2024-01-02", "2024-01-02T00:00:00Z", false},
+		{"1704164645", "2024-01-02T03:04:05Z", false},
+		{" 1704164645 ", "2024-01-02T03:04:05Z", false},
+		{"1704164645.5", "2024-01-02T03:04:05.5Z", false},
+		{"2024", "", true},          // a year, not 1970-01-01T00:33:44Z
+		{"20240102", "", true},      // a compact date, not 1970-08-23
+		{"1704164645000", "", true}, // milliseconds
+		{"NaN", "", true},
+		{"Inf", "", true},
+		{"-Inf", "", true},
+		{"1e300", "", true},
+		{"1.7e9", "", true},
+		{"-1704164645", "", true},
+		{"0x65940A25", "", true},
+		{"yesterday", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParsePublishedAt(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePublishedAt(%q) error = %v, want error %v", tt.in, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if tt.want == "" {
+				if !got.IsZero() {
+					t.Errorf("ParsePublishedAt(%q) = %v, want the zero time", tt.in, got)
+				}
+				return
+			}
+			if s := got.Format(time.RFC3339Nano); s != tt.want {
+				t.Errorf("ParsePublishedAt(%q) = %s, want %s", tt.in, s, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePublishedAtExtraLayout(t *testing.T) {
+	saved := publishedAtLayouts
+	defer func() { publishedAtLayouts = saved }()
+	publishedAtLayouts = append([]string{}, saved...)
+
+	// with a year layout registered, "2024" is the year, not epoch seconds
+	AddPublishedAtLayouts("2006")
+	got, err := ParsePublishedAt("2024")
+	if err != nil || got.Year() != 2024 {
+		t.Errorf(`ParsePublishedAt("2024") = %v, %v, want 2024-01-01`, got, err)
+	}
+}
+
+func TestArticleUnmarshalPublishedAt(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{`{"published_at":1704164645}`, "2024-01-02T03:04:05Z", false},
+		{`{"published_at":"1704164645"}`, "2024-01-02T03:04:05Z", false},
+		{`{"published_at":"2024-01-02T03:04:05Z"}`, "2024-01-02T03:04:05Z", false},
+		{`{"published_at":null}`, "0001-01-01T00:00:00Z", false},
+		{`{"published_at":2024}`, "", true},
+		{`{"published_at":1e300}`, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			var a Article
+			err := json.Unmarshal([]byte(tt.in), &a)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Unmarshal error = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && a.PublishedAt.Format(time.RFC3339) != tt.want {
+				t.Errorf("published_at = %s, want %s", a.PublishedAt.Format(time.RFC3339), tt.want)
+			}
+		})
+	}
+}